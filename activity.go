@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/logrusorgru/aurora"
+)
+
+type activity struct {
+	unread    int
+	highlight bool
+}
+
+type activityTracker struct {
+	sync.Mutex
+	buffers map[string]activity
+}
+
+func (a *activityTracker) Message(buffer string, highlight bool) {
+	a.Lock()
+	defer a.Unlock()
+
+	ba := a.buffers[buffer]
+	ba.unread++
+	ba.highlight = ba.highlight || highlight
+	a.buffers[buffer] = ba
+}
+
+func (a *activityTracker) Clear(buffer string) {
+	a.Lock()
+	defer a.Unlock()
+
+	delete(a.buffers, buffer)
+}
+
+func (a *activityTracker) Get(buffer string) activity {
+	a.Lock()
+	defer a.Unlock()
+
+	return a.buffers[buffer]
+}
+
+func createActivityTracker() *activityTracker {
+	return &activityTracker{
+		buffers: make(map[string]activity),
+	}
+}
+
+func formatSidebarEntry(buffer string, active bool, ba activity) string {
+	out := buffer
+
+	if ba.unread > 0 {
+		out = fmt.Sprintf("%s %d", buffer, ba.unread)
+	}
+
+	if ba.highlight {
+		return aurora.Red("!" + out).Bold().String()
+	}
+
+	if active {
+		return aurora.Green(" " + out).Bold().String()
+	}
+
+	if ba.unread > 0 {
+		return aurora.Bold(" " + out).String()
+	}
+
+	return " " + out
+}
+
+func sidebarWidth(buffers []string) int {
+	w := 0
+
+	for _, b := range buffers {
+		if len(b) > w {
+			w = len(b)
+		}
+	}
+
+	// leave room for the marker and unread count
+	return w + 6
+}
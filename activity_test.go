@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityTracker(t *testing.T) {
+	at := createActivityTracker()
+
+	assert.Equal(t, activity{}, at.Get("#nako"))
+
+	at.Message("#nako", false)
+	at.Message("#nako", true)
+	at.Message("#nako", false)
+	at.Message("#gowon", false)
+
+	assert.Equal(t, activity{unread: 3, highlight: true}, at.Get("#nako"))
+	assert.Equal(t, activity{unread: 1, highlight: false}, at.Get("#gowon"))
+
+	at.Clear("#nako")
+
+	assert.Equal(t, activity{}, at.Get("#nako"))
+	assert.Equal(t, activity{unread: 1, highlight: false}, at.Get("#gowon"))
+}
+
+func TestFormatSidebarEntry(t *testing.T) {
+	cases := []struct {
+		name   string
+		buffer string
+		active bool
+		ba     activity
+		out    string
+	}{
+		{
+			name:   "inactive, no activity",
+			buffer: "#nako",
+			out:    " #nako",
+		},
+		{
+			name:   "active",
+			buffer: "#nako",
+			active: true,
+			out:    "\x1b[1;32m #nako\x1b[0m",
+		},
+		{
+			name:   "unread",
+			buffer: "#nako",
+			ba:     activity{unread: 2},
+			out:    "\x1b[1m #nako 2\x1b[0m",
+		},
+		{
+			name:   "highlight",
+			buffer: "#nako",
+			ba:     activity{unread: 2, highlight: true},
+			out:    "\x1b[1;31m!#nako 2\x1b[0m",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := formatSidebarEntry(tc.buffer, tc.active, tc.ba)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
+
+func TestSidebarWidth(t *testing.T) {
+	cases := []struct {
+		name string
+		in   []string
+		out  int
+	}{
+		{
+			name: "no buffers",
+			in:   []string{},
+			out:  6,
+		},
+		{
+			name: "longest buffer",
+			in:   []string{"status", "#nako", "#gowon-irc"},
+			out:  16,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sidebarWidth(tc.in)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
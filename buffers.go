@@ -0,0 +1,172 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	statusBuffer   = "status"
+	chatViewPrefix = "chat:"
+	ownMessageNick = "you"
+)
+
+func chatViewName(buffer string) string {
+	return chatViewPrefix + buffer
+}
+
+func isChatView(name string) bool {
+	return strings.HasPrefix(name, chatViewPrefix)
+}
+
+// messageBuffer returns the buffer a message belongs in: its channel, or for
+// private messages a query buffer named after the other person
+func messageBuffer(nick, dest, ownNick string) string {
+	if isChannel(dest) || nick == ownMessageNick {
+		return dest
+	}
+
+	if ownNick != "" && !strings.EqualFold(dest, ownNick) {
+		return dest
+	}
+
+	return nick
+}
+
+type bufferList struct {
+	sync.Mutex
+	names         []string
+	active        int
+	loggers       map[string]*logger
+	genLoggerFunc func(buffer string) func(s string, t time.Time)
+	onRemove      func(buffer string)
+	clock         *clock
+}
+
+func (b *bufferList) add(name string) *logger {
+	if l, p := b.loggers[name]; p {
+		return l
+	}
+
+	l := createLogger(b.genLoggerFunc(name), b.clock)
+	b.names = append(b.names, name)
+	b.loggers[name] = l
+
+	return l
+}
+
+func (b *bufferList) Logger(name string) *logger {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.add(name)
+}
+
+// Get returns the logger of an existing buffer without adding one
+func (b *bufferList) Get(name string) (*logger, bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	l, p := b.loggers[name]
+
+	return l, p
+}
+
+func (b *bufferList) Names() []string {
+	b.Lock()
+	defer b.Unlock()
+
+	names := make([]string, len(b.names))
+	copy(names, b.names)
+
+	return names
+}
+
+func (b *bufferList) Active() string {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.names[b.active]
+}
+
+func (b *bufferList) Cycle(d int) {
+	b.Lock()
+	defer b.Unlock()
+
+	n := len(b.names)
+	b.active = ((b.active+d)%n + n) % n
+}
+
+func (b *bufferList) Select(i int) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if i < 0 || i >= len(b.names) {
+		return false
+	}
+
+	b.active = i
+
+	return true
+}
+
+// Open makes a buffer active, adding it if needed
+func (b *bufferList) Open(name string) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.add(name)
+
+	for i, n := range b.names {
+		if n == name {
+			b.active = i
+		}
+	}
+}
+
+// Remove drops a buffer, keeping the same buffer active where possible. The
+// status buffer can't be removed.
+func (b *bufferList) Remove(name string) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	for i, n := range b.names {
+		if n != name || n == statusBuffer {
+			continue
+		}
+
+		b.names = append(b.names[:i], b.names[i+1:]...)
+		delete(b.loggers, name)
+		b.onRemove(name)
+
+		if b.active > i || b.active == len(b.names) {
+			b.active--
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func createBufferList(f func(buffer string) func(s string, t time.Time), onRemove func(buffer string), ck *clock, names ...string) *bufferList {
+	b := &bufferList{
+		loggers:       make(map[string]*logger),
+		genLoggerFunc: f,
+		onRemove:      onRemove,
+		clock:         ck,
+	}
+
+	b.add(statusBuffer)
+
+	for _, n := range names {
+		b.add(n)
+	}
+
+	if len(names) > 0 {
+		b.active = 1
+	}
+
+	return b
+}
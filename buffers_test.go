@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBufferList(names ...string) *bufferList {
+	f := func(buffer string) func(s string, t time.Time) {
+		return genWriterLoggerFunc(&bytes.Buffer{})
+	}
+
+	return createBufferList(f, func(buffer string) {}, testClock(), names...)
+}
+
+func TestCreateBufferList(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     []string
+		names  []string
+		active string
+	}{
+		{
+			name:   "no channels",
+			in:     []string{},
+			names:  []string{statusBuffer},
+			active: statusBuffer,
+		},
+		{
+			name:   "one channel",
+			in:     []string{"#nako"},
+			names:  []string{statusBuffer, "#nako"},
+			active: "#nako",
+		},
+		{
+			name:   "duplicate channels",
+			in:     []string{"#nako", "#gowon", "#nako"},
+			names:  []string{statusBuffer, "#nako", "#gowon"},
+			active: "#nako",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bl := testBufferList(tc.in...)
+			assert.Equal(t, tc.names, bl.Names())
+			assert.Equal(t, tc.active, bl.Active())
+		})
+	}
+}
+
+func TestBufferListLogger(t *testing.T) {
+	bl := testBufferList("#nako")
+
+	l := bl.Logger("#nako")
+	assert.Same(t, l, bl.Logger("#nako"))
+	assert.Equal(t, []string{statusBuffer, "#nako"}, bl.Names())
+
+	_ = bl.Logger("#gowon")
+	assert.Equal(t, []string{statusBuffer, "#nako", "#gowon"}, bl.Names())
+}
+
+func TestBufferListGet(t *testing.T) {
+	bl := testBufferList("#nako")
+
+	l, ok := bl.Get("#nako")
+	assert.True(t, ok)
+	assert.Same(t, bl.Logger("#nako"), l)
+
+	_, ok = bl.Get("#gowon")
+	assert.False(t, ok)
+	assert.Equal(t, []string{statusBuffer, "#nako"}, bl.Names())
+}
+
+func TestBufferListCycle(t *testing.T) {
+	cases := []struct {
+		name     string
+		d        int
+		expected string
+	}{
+		{
+			name:     "next",
+			d:        1,
+			expected: "#gowon",
+		},
+		{
+			name:     "previous",
+			d:        -1,
+			expected: statusBuffer,
+		},
+		{
+			name:     "wrap forwards",
+			d:        2,
+			expected: statusBuffer,
+		},
+		{
+			name:     "wrap backwards",
+			d:        -2,
+			expected: "#gowon",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bl := testBufferList("#nako", "#gowon")
+			bl.Cycle(tc.d)
+			assert.Equal(t, tc.expected, bl.Active())
+		})
+	}
+}
+
+func TestBufferListSelect(t *testing.T) {
+	cases := []struct {
+		name     string
+		i        int
+		ok       bool
+		expected string
+	}{
+		{
+			name:     "status buffer",
+			i:        0,
+			ok:       true,
+			expected: statusBuffer,
+		},
+		{
+			name:     "channel buffer",
+			i:        2,
+			ok:       true,
+			expected: "#gowon",
+		},
+		{
+			name:     "out of range",
+			i:        3,
+			ok:       false,
+			expected: "#nako",
+		},
+		{
+			name:     "negative",
+			i:        -1,
+			ok:       false,
+			expected: "#nako",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bl := testBufferList("#nako", "#gowon")
+			ok := bl.Select(tc.i)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, bl.Active())
+		})
+	}
+}
+
+func TestMessageBuffer(t *testing.T) {
+	cases := []struct {
+		name    string
+		nick    string
+		dest    string
+		ownNick string
+		out     string
+	}{
+		{
+			name:    "channel",
+			nick:    "gowon",
+			dest:    "#nako",
+			ownNick: "nako",
+			out:     "#nako",
+		},
+		{
+			name:    "private message to us",
+			nick:    "gowon",
+			dest:    "Nako",
+			ownNick: "nako",
+			out:     "gowon",
+		},
+		{
+			name:    "private message with own nick unknown",
+			nick:    "gowon",
+			dest:    "nako",
+			ownNick: "",
+			out:     "gowon",
+		},
+		{
+			name:    "our own private message",
+			nick:    ownMessageNick,
+			dest:    "gowon",
+			ownNick: "nako",
+			out:     "gowon",
+		},
+		{
+			name:    "our private message from another client",
+			nick:    "nako",
+			dest:    "gowon",
+			ownNick: "nako",
+			out:     "gowon",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, messageBuffer(tc.nick, tc.dest, tc.ownNick))
+		})
+	}
+}
+
+func TestBufferListOpen(t *testing.T) {
+	bl := testBufferList("#nako")
+
+	bl.Open("gowon")
+	assert.Equal(t, []string{statusBuffer, "#nako", "gowon"}, bl.Names())
+	assert.Equal(t, "gowon", bl.Active())
+
+	bl.Open("#nako")
+	assert.Equal(t, []string{statusBuffer, "#nako", "gowon"}, bl.Names())
+	assert.Equal(t, "#nako", bl.Active())
+}
+
+func TestBufferListRemove(t *testing.T) {
+	bl := testBufferList("#nako", "#gowon", "#kaon")
+
+	bl.Select(3)
+	assert.True(t, bl.Remove("#nako"))
+	assert.Equal(t, []string{statusBuffer, "#gowon", "#kaon"}, bl.Names())
+	assert.Equal(t, "#kaon", bl.Active())
+
+	assert.True(t, bl.Remove("#kaon"))
+	assert.Equal(t, "#gowon", bl.Active())
+
+	assert.False(t, bl.Remove("#kaon"))
+	assert.False(t, bl.Remove(statusBuffer))
+	assert.Equal(t, []string{statusBuffer, "#gowon"}, bl.Names())
+}
+
+func TestBufferListRemoveCallsOnRemove(t *testing.T) {
+	removed := []string{}
+	f := func(buffer string) func(s string, t time.Time) {
+		return genWriterLoggerFunc(&bytes.Buffer{})
+	}
+
+	bl := createBufferList(f, func(buffer string) {
+		removed = append(removed, buffer)
+	}, testClock(), "#nako", "#gowon")
+
+	bl.Remove("#nako")
+	bl.Remove("#nako")
+	bl.Remove(statusBuffer)
+
+	assert.Equal(t, []string{"#nako"}, removed)
+}
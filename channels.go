@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// channelSet holds the channels nako is in, shared by the handlers, the
+// layout and the connect handler so runtime joins and parts survive
+// reconnecting. Starting with no channels watches every channel. Keys given
+// to /join are kept so keyed channels can be rejoined.
+type channelSet struct {
+	sync.Mutex
+	all      bool
+	channels []string
+	keys     map[string]string
+}
+
+func (cs *channelSet) Watching(channel string) bool {
+	cs.Lock()
+	defer cs.Unlock()
+
+	return cs.all || containsString(cs.channels, channel)
+}
+
+func (cs *channelSet) List() []string {
+	cs.Lock()
+	defer cs.Unlock()
+
+	return append([]string{}, cs.channels...)
+}
+
+func (cs *channelSet) Key(channel string) string {
+	cs.Lock()
+	defer cs.Unlock()
+
+	return cs.keys[channel]
+}
+
+func (cs *channelSet) Add(channel, key string) bool {
+	cs.Lock()
+	defer cs.Unlock()
+
+	if key != "" {
+		cs.keys[channel] = key
+	}
+
+	if containsString(cs.channels, channel) {
+		return false
+	}
+
+	cs.channels = append(cs.channels, channel)
+
+	return true
+}
+
+func (cs *channelSet) Remove(channel string) bool {
+	cs.Lock()
+	defer cs.Unlock()
+
+	delete(cs.keys, channel)
+
+	for i, c := range cs.channels {
+		if c == channel {
+			cs.channels = append(cs.channels[:i], cs.channels[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func createChannelSet(channels ...string) *channelSet {
+	return &channelSet{
+		all:      len(channels) == 0,
+		channels: append([]string{}, channels...),
+		keys:     map[string]string{},
+	}
+}
+
+// joinChannelName adds a # to channel names given without a prefix
+func joinChannelName(name string) string {
+	if isChannel(name) {
+		return name
+	}
+
+	return "#" + name
+}
+
+func formatJoinCommand(channel, key string) string {
+	return strings.TrimSpace("JOIN " + channel + " " + key)
+}
+
+// formatRejoinCommand joins every channel in one command, keyed channels
+// first so the keys line up with them
+func formatRejoinCommand(channels []string, key func(channel string) string) string {
+	keyed, keys, open := []string{}, []string{}, []string{}
+
+	for _, c := range channels {
+		if k := key(c); k != "" {
+			keyed = append(keyed, c)
+			keys = append(keys, k)
+		} else {
+			open = append(open, c)
+		}
+	}
+
+	return formatJoinCommand(strings.Join(append(keyed, open...), ","), strings.Join(keys, ","))
+}
+
+func formatPartCommand(channel, reason string) string {
+	if reason == "" {
+		return "PART " + channel
+	}
+
+	return "PART " + channel + " :" + reason
+}
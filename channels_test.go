@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelSetWatching(t *testing.T) {
+	cases := []struct {
+		name     string
+		channels []string
+		channel  string
+		out      bool
+	}{
+		{
+			name:     "no channels watches everything",
+			channels: []string{},
+			channel:  "#nako",
+			out:      true,
+		},
+		{
+			name:     "watched channel",
+			channels: []string{"#nako"},
+			channel:  "#nako",
+			out:      true,
+		},
+		{
+			name:     "unwatched channel",
+			channels: []string{"#nako"},
+			channel:  "#gowon",
+			out:      false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := createChannelSet(tc.channels...).Watching(tc.channel)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
+
+func TestChannelSetAddRemove(t *testing.T) {
+	cs := createChannelSet("#nako")
+
+	assert.True(t, cs.Add("#gowon", ""))
+	assert.False(t, cs.Add("#gowon", ""))
+	assert.Equal(t, []string{"#nako", "#gowon"}, cs.List())
+	assert.True(t, cs.Watching("#gowon"))
+
+	assert.True(t, cs.Remove("#nako"))
+	assert.False(t, cs.Remove("#nako"))
+	assert.Equal(t, []string{"#gowon"}, cs.List())
+	assert.False(t, cs.Watching("#nako"))
+
+	cs.Remove("#gowon")
+	assert.False(t, cs.Watching("#nako"))
+}
+
+func TestChannelSetKey(t *testing.T) {
+	cs := createChannelSet("#nako")
+
+	assert.True(t, cs.Add("#gowon", "secret"))
+	assert.Equal(t, "secret", cs.Key("#gowon"))
+	assert.Equal(t, "", cs.Key("#nako"))
+
+	assert.False(t, cs.Add("#nako", "other"))
+	assert.Equal(t, "other", cs.Key("#nako"))
+
+	cs.Remove("#gowon")
+	assert.Equal(t, "", cs.Key("#gowon"))
+}
+
+func TestFormatRejoinCommand(t *testing.T) {
+	cases := []struct {
+		name     string
+		channels []string
+		keys     map[string]string
+		out      string
+	}{
+		{
+			name:     "no keys",
+			channels: []string{"#nako", "#gowon"},
+			keys:     map[string]string{},
+			out:      "JOIN #nako,#gowon",
+		},
+		{
+			name:     "keyed channel after reconnecting",
+			channels: []string{"#nako", "#gowon", "#kaon"},
+			keys:     map[string]string{"#gowon": "secret"},
+			out:      "JOIN #gowon,#nako,#kaon secret",
+		},
+		{
+			name:     "several keys",
+			channels: []string{"#nako", "#gowon"},
+			keys:     map[string]string{"#nako": "a", "#gowon": "b"},
+			out:      "JOIN #nako,#gowon a,b",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := createChannelSet()
+			for _, c := range tc.channels {
+				cs.Add(c, tc.keys[c])
+			}
+
+			assert.Equal(t, tc.out, formatRejoinCommand(cs.List(), cs.Key))
+		})
+	}
+}
+
+func TestJoinChannelName(t *testing.T) {
+	assert.Equal(t, "#nako", joinChannelName("nako"))
+	assert.Equal(t, "#nako", joinChannelName("#nako"))
+	assert.Equal(t, "&nako", joinChannelName("&nako"))
+}
+
+func TestFormatJoinPartCommands(t *testing.T) {
+	assert.Equal(t, "JOIN #nako", formatJoinCommand("#nako", ""))
+	assert.Equal(t, "JOIN #nako secret", formatJoinCommand("#nako", "secret"))
+	assert.Equal(t, "PART #nako", formatPartCommand("#nako", ""))
+	assert.Equal(t, "PART #nako :see you", formatPartCommand("#nako", "see you"))
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	chatHistoryBatch = "chathistory"
+	chatHistoryLimit = 50
+	maxSeenMsgIDs    = 5000
+	dateHeaderFormat = "Mon 02 Jan 2006"
+)
+
+type historyLine struct {
+	msgid string
+	at    time.Time
+	text  string
+}
+
+type chatBatch struct {
+	target string
+	lines  []historyLine
+}
+
+// batchTracker buffers chathistory batches until they close and remembers the
+// msgids already shown in each buffer so replayed messages aren't repeated
+type batchTracker struct {
+	sync.Mutex
+	open      map[string]*chatBatch
+	seen      map[string][]string
+	oldest    map[string]historyLine
+	since     map[string]time.Time
+	requested map[string]string
+}
+
+func (bt *batchTracker) Open(ref, kind, target string) {
+	if kind != chatHistoryBatch {
+		return
+	}
+
+	bt.Lock()
+	defer bt.Unlock()
+
+	bt.open[ref] = &chatBatch{target: target}
+}
+
+func (bt *batchTracker) Add(ref string, hl historyLine) bool {
+	bt.Lock()
+	defer bt.Unlock()
+
+	b, p := bt.open[ref]
+	if !p {
+		return false
+	}
+
+	b.lines = append(b.lines, hl)
+
+	return true
+}
+
+func (bt *batchTracker) see(buffer string, hl historyLine) bool {
+	if o, p := bt.oldest[buffer]; !p || hl.at.Before(o.at) {
+		bt.oldest[buffer] = hl
+	}
+
+	if hl.msgid == "" {
+		return true
+	}
+
+	for _, id := range bt.seen[buffer] {
+		if id == hl.msgid {
+			return false
+		}
+	}
+
+	seen := append(bt.seen[buffer], hl.msgid)
+	if len(seen) > maxSeenMsgIDs {
+		seen = seen[len(seen)-maxSeenMsgIDs:]
+	}
+	bt.seen[buffer] = seen
+
+	return true
+}
+
+// See records a line as shown in a buffer, reporting whether its msgid is new
+func (bt *batchTracker) See(buffer string, hl historyLine) bool {
+	bt.Lock()
+	defer bt.Unlock()
+
+	return bt.see(buffer, hl)
+}
+
+// Before returns the CHATHISTORY reference for the oldest line shown in a
+// buffer, or false if history before it has already been asked for. Buffers
+// with nothing shown use the time of their first request so repeated requests
+// are still caught.
+func (bt *batchTracker) Before(buffer string, now time.Time) (string, bool) {
+	bt.Lock()
+	defer bt.Unlock()
+
+	if _, p := bt.since[buffer]; !p {
+		bt.since[buffer] = now
+	}
+
+	ref := "timestamp=" + bt.since[buffer].UTC().Format(serverTimeFormat)
+
+	if o, p := bt.oldest[buffer]; p && o.msgid != "" {
+		ref = "msgid=" + o.msgid
+	} else if p {
+		ref = "timestamp=" + o.at.UTC().Format(serverTimeFormat)
+	}
+
+	if bt.requested[buffer] == ref {
+		return "", false
+	}
+
+	bt.requested[buffer] = ref
+
+	return ref, true
+}
+
+// Close ends a batch, returning its target and unseen lines in server time
+// order
+func (bt *batchTracker) Close(ref string) (string, []historyLine, bool) {
+	bt.Lock()
+	defer bt.Unlock()
+
+	b, p := bt.open[ref]
+	if !p {
+		return "", nil, false
+	}
+
+	delete(bt.open, ref)
+
+	sort.SliceStable(b.lines, func(i, j int) bool {
+		return b.lines[i].at.Before(b.lines[j].at)
+	})
+
+	lines := []historyLine{}
+	for _, hl := range b.lines {
+		if bt.see(b.target, hl) {
+			lines = append(lines, hl)
+		}
+	}
+
+	if len(b.lines) > 0 {
+		delete(bt.since, b.target)
+	}
+
+	return b.target, lines, true
+}
+
+func createBatchTracker() *batchTracker {
+	return &batchTracker{
+		open:      make(map[string]*chatBatch),
+		seen:      make(map[string][]string),
+		oldest:    make(map[string]historyLine),
+		since:     make(map[string]time.Time),
+		requested: make(map[string]string),
+	}
+}
+
+// parseBatch splits the reference tag of a BATCH message into its reference
+// and whether the batch is being opened
+func parseBatch(arg string) (ref string, opening bool) {
+	if arg == "" {
+		return "", false
+	}
+
+	return arg[1:], strings.HasPrefix(arg, "+")
+}
+
+func formatDateHeader(t time.Time) string {
+	return "--- " + t.Format(dateHeaderFormat) + " ---"
+}
+
+// formatHistory turns ordered history lines into scrollback lines, with a date
+// header ahead of the first line of each day
+func formatHistory(lines []historyLine, ck *clock) []scrollbackLine {
+	formatted := []scrollbackLine{}
+	date := ""
+
+	for _, hl := range lines {
+		if d := ck.Date(hl.at); d != date {
+			formatted = append(formatted, scrollbackLine{at: hl.at, text: formatDateHeader(ck.In(hl.at))})
+			date = d
+		}
+
+		formatted = append(formatted, scrollbackLine{at: hl.at, text: hl.text})
+	}
+
+	return formatted
+}
+
+func genRequestHistoryBefore(c mqtt.Client, topicRoot string, bt *batchTracker) func(buffer string) {
+	rawOutputTopic := topicRoot + "/raw/output"
+
+	return func(buffer string) {
+		if buffer == statusBuffer {
+			return
+		}
+
+		if ref, ok := bt.Before(buffer, time.Now()); ok {
+			c.Publish(rawOutputTopic, 0, false, fmt.Sprintf("CHATHISTORY BEFORE %s %s %d", buffer, ref, chatHistoryLimit))
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBatch(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		ref     string
+		opening bool
+	}{
+		{
+			name:    "opening",
+			in:      "+abc",
+			ref:     "abc",
+			opening: true,
+		},
+		{
+			name:    "closing",
+			in:      "-abc",
+			ref:     "abc",
+			opening: false,
+		},
+		{
+			name:    "empty",
+			in:      "",
+			ref:     "",
+			opening: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, opening := parseBatch(tc.in)
+			assert.Equal(t, tc.ref, ref)
+			assert.Equal(t, tc.opening, opening)
+		})
+	}
+}
+
+func TestBatchTracker(t *testing.T) {
+	bt := createBatchTracker()
+	base := time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)
+
+	assert.True(t, bt.See("#nako", historyLine{msgid: "2", at: base.Add(time.Minute)}))
+	assert.False(t, bt.See("#nako", historyLine{msgid: "2", at: base.Add(time.Minute)}))
+	assert.True(t, bt.See("#nako", historyLine{at: base.Add(time.Hour)}))
+	assert.True(t, bt.See("#nako", historyLine{at: base.Add(time.Hour)}))
+
+	assert.False(t, bt.Add("abc", historyLine{msgid: "1"}))
+
+	bt.Open("netsplit", "netsplit", "#nako")
+	assert.False(t, bt.Add("netsplit", historyLine{msgid: "1"}))
+
+	bt.Open("abc", chatHistoryBatch, "#nako")
+	assert.True(t, bt.Add("abc", historyLine{msgid: "3", at: base.Add(2 * time.Minute), text: "c"}))
+	assert.True(t, bt.Add("abc", historyLine{msgid: "1", at: base, text: "a"}))
+	assert.True(t, bt.Add("abc", historyLine{msgid: "2", at: base.Add(time.Minute), text: "b"}))
+
+	target, lines, ok := bt.Close("abc")
+	assert.True(t, ok)
+	assert.Equal(t, "#nako", target)
+	assert.Equal(t, []historyLine{
+		{msgid: "1", at: base, text: "a"},
+		{msgid: "3", at: base.Add(2 * time.Minute), text: "c"},
+	}, lines)
+
+	assert.False(t, bt.See("#nako", historyLine{msgid: "3", at: base.Add(2 * time.Minute)}))
+
+	_, _, ok = bt.Close("abc")
+	assert.False(t, ok)
+}
+
+func TestFormatHistory(t *testing.T) {
+	first := time.Date(2022, 7, 19, 23, 59, 0, 0, time.UTC)
+	second := time.Date(2022, 7, 20, 0, 1, 0, 0, time.UTC)
+
+	got := formatHistory([]historyLine{
+		{at: first, text: "a"},
+		{at: first, text: "b"},
+		{at: second, text: "c"},
+	}, testClock())
+
+	assert.Equal(t, []scrollbackLine{
+		{at: first, text: "--- Tue 19 Jul 2022 ---"},
+		{at: first, text: "a"},
+		{at: first, text: "b"},
+		{at: second, text: "--- Wed 20 Jul 2022 ---"},
+		{at: second, text: "c"},
+	}, got)
+
+	assert.Equal(t, []scrollbackLine{}, formatHistory([]historyLine{}, testClock()))
+}
+
+func TestBatchTrackerBefore(t *testing.T) {
+	bt := createBatchTracker()
+	base := time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)
+
+	ref, ok := bt.Before("#nako", base)
+	assert.True(t, ok)
+	assert.Equal(t, "timestamp=2022-07-19T11:53:00.000Z", ref)
+
+	_, ok = bt.Before("#nako", base)
+	assert.False(t, ok)
+
+	_, ok = bt.Before("#nako", base.Add(time.Second))
+	assert.False(t, ok)
+
+	bt.Open("empty", chatHistoryBatch, "#nako")
+	bt.Close("empty")
+
+	_, ok = bt.Before("#nako", base.Add(time.Minute))
+	assert.False(t, ok)
+
+	bt.See("#nako", historyLine{at: base.Add(-time.Hour)})
+	ref, _ = bt.Before("#nako", base)
+	assert.Equal(t, "timestamp=2022-07-19T10:53:00.000Z", ref)
+
+	bt.See("#nako", historyLine{msgid: "1", at: base.Add(-2 * time.Hour)})
+	bt.See("#nako", historyLine{msgid: "2", at: base.Add(-time.Minute)})
+	ref, ok = bt.Before("#nako", base)
+	assert.True(t, ok)
+	assert.Equal(t, "msgid=1", ref)
+
+	_, ok = bt.Before("#nako", base)
+	assert.False(t, ok)
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	chatLogDateFormat = "2006-01-02"
+	chatLogExt        = ".log"
+	chatLogJSONExt    = ".jsonl"
+	restoredMarker    = "--- restored ---"
+)
+
+var ansiEscapeRegex = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func stripFormatting(s string) string {
+	return ansiEscapeRegex.ReplaceAllString(ircToAnsiColours(s), "")
+}
+
+// chatLogBufferDir returns the directory holding a buffer's logs, with path
+// separators in the buffer name replaced so it stays inside the log directory
+func chatLogBufferDir(dir, buffer string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(buffer)
+	if name == "" || name == "." || name == ".." {
+		name = "_" + name
+	}
+
+	return filepath.Join(dir, name)
+}
+
+// rotatingWriter appends to one file per buffer per day, opening the next
+// file when the date changes
+type rotatingWriter struct {
+	sync.Mutex
+	dir     string
+	ext     string
+	now     func() time.Time
+	onError func(err error)
+	date    string
+	file    *os.File
+}
+
+func (rw *rotatingWriter) rotate() error {
+	date := rw.now().Format(chatLogDateFormat)
+	if rw.file != nil && date == rw.date {
+		return nil
+	}
+
+	if rw.file != nil {
+		if err := rw.file.Close(); err != nil {
+			rw.onError(err)
+		}
+		rw.file = nil
+	}
+
+	if err := os.MkdirAll(rw.dir, 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(rw.dir, date+rw.ext), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	rw.date, rw.file = date, f
+
+	return nil
+}
+
+func (rw *rotatingWriter) Write(p []byte) (int, error) {
+	rw.Lock()
+	defer rw.Unlock()
+
+	if err := rw.rotate(); err != nil {
+		rw.onError(err)
+		return 0, err
+	}
+
+	n, err := rw.file.Write(p)
+	if err != nil {
+		rw.onError(err)
+	}
+
+	return n, err
+}
+
+func (rw *rotatingWriter) Close() error {
+	rw.Lock()
+	defer rw.Unlock()
+
+	if rw.file == nil {
+		return nil
+	}
+
+	err := rw.file.Close()
+	rw.file = nil
+
+	return err
+}
+
+type plainWriter struct {
+	w io.Writer
+}
+
+func (pw plainWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(pw.w, stripFormatting(string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+type chatLogLine struct {
+	Time   string `json:"time"`
+	Buffer string `json:"buffer"`
+	Text   string `json:"text"`
+}
+
+// jsonLinesWriter writes lines as json, stamped with the time of the message
+// rather than when it's written so replayed lines keep their time
+type jsonLinesWriter struct {
+	w      io.Writer
+	buffer string
+}
+
+func (jw jsonLinesWriter) Log(s string, t time.Time) {
+	line := chatLogLine{
+		Time:   t.Format(time.RFC3339),
+		Buffer: jw.buffer,
+		Text:   stripFormatting(s),
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+
+	jw.w.Write(append(data, '\n'))
+}
+
+type chatLog struct {
+	sync.Mutex
+	dir     string
+	json    bool
+	now     func() time.Time
+	onError func(err error)
+	failed  bool
+	writers map[string][]*rotatingWriter
+}
+
+// fail reports the first write failure. It's reported from its own goroutine
+// as the failing write may be to the status buffer's log.
+func (cl *chatLog) fail(err error) {
+	cl.Lock()
+	first := !cl.failed
+	cl.failed = true
+	cl.Unlock()
+
+	if first {
+		go cl.onError(err)
+	}
+}
+
+func (cl *chatLog) writer(buffer, ext string) *rotatingWriter {
+	cl.Lock()
+	defer cl.Unlock()
+
+	rw := &rotatingWriter{dir: chatLogBufferDir(cl.dir, buffer), ext: ext, now: cl.now, onError: cl.fail}
+	cl.writers[buffer] = append(cl.writers[buffer], rw)
+
+	return rw
+}
+
+// LoggerFunc returns a logger func writing a buffer's lines to its plain
+// text log and, if enabled, its json lines log
+func (cl *chatLog) LoggerFunc(buffer string) func(s string, t time.Time) {
+	plain := genWriterLoggerFunc(plainWriter{w: cl.writer(buffer, chatLogExt)})
+
+	if !cl.json {
+		return plain
+	}
+
+	jsonLines := jsonLinesWriter{w: cl.writer(buffer, chatLogJSONExt), buffer: buffer}.Log
+
+	return func(s string, t time.Time) {
+		plain(s, t)
+		jsonLines(s, t)
+	}
+}
+
+// CloseBuffer closes the logs of a removed buffer
+func (cl *chatLog) CloseBuffer(buffer string) {
+	cl.Lock()
+	defer cl.Unlock()
+
+	for _, rw := range cl.writers[buffer] {
+		rw.Close()
+	}
+
+	delete(cl.writers, buffer)
+}
+
+func (cl *chatLog) Close() {
+	cl.Lock()
+	defer cl.Unlock()
+
+	for _, rws := range cl.writers {
+		for _, rw := range rws {
+			rw.Close()
+		}
+	}
+}
+
+func createChatLog(dir string, json bool, onError func(err error)) *chatLog {
+	return &chatLog{
+		dir:     dir,
+		json:    json,
+		now:     time.Now,
+		onError: onError,
+		writers: map[string][]*rotatingWriter{},
+	}
+}
+
+// readChatLogTail returns up to the last n lines of a buffer's plain text
+// logs, reading back through older days as needed
+func readChatLogTail(dir, buffer string, n int) ([]string, error) {
+	bufferDir := chatLogBufferDir(dir, buffer)
+
+	entries, err := os.ReadDir(bufferDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), chatLogExt) {
+			files = append(files, filepath.Join(bufferDir, e.Name()))
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+
+	lines := []string{}
+
+	for _, f := range files {
+		if len(lines) >= n {
+			break
+		}
+
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return lines, err
+		}
+
+		content := strings.TrimSuffix(string(data), "\n")
+		if content == "" {
+			continue
+		}
+
+		lines = append(strings.Split(content, "\n"), lines...)
+	}
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return lines, nil
+}
+
+// restoreChatLog inserts logged lines ahead of anything already shown, as
+// their original times aren't kept in the plain text logs
+func restoreChatLog(dir, buffer string, n int, sb *scrollback) error {
+	if n <= 0 {
+		return nil
+	}
+
+	lines, err := readChatLogTail(dir, buffer, n)
+	if err != nil || len(lines) == 0 {
+		return err
+	}
+
+	restored := []scrollbackLine{}
+	for _, l := range append(lines, restoredMarker) {
+		restored = append(restored, scrollbackLine{text: l})
+	}
+
+	sb.Insert(buffer, restored)
+
+	return nil
+}
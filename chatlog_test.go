@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripFormatting(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "plain",
+			in:   "hello",
+			out:  "hello",
+		},
+		{
+			name: "ansi",
+			in:   "\x1b[1m11:53\x1b[0m \x1b[38;5;3mgowon: hello\x1b[0m",
+			out:  "11:53 gowon: hello",
+		},
+		{
+			name: "irc",
+			in:   "\x02bold\x02 \x0304,01red\x03",
+			out:  "bold red",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, stripFormatting(tc.in))
+		})
+	}
+}
+
+func TestChatLogBufferDir(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "channel",
+			in:   "#nako",
+			out:  filepath.Join("logs", "#nako"),
+		},
+		{
+			name: "separators",
+			in:   "#a/../b",
+			out:  filepath.Join("logs", "#a_.._b"),
+		},
+		{
+			name: "parent",
+			in:   "..",
+			out:  filepath.Join("logs", "_.."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, chatLogBufferDir("logs", tc.in))
+		})
+	}
+}
+
+func TestRotatingWriter(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2022, 7, 19, 23, 59, 0, 0, time.UTC)
+
+	rw := &rotatingWriter{dir: dir, ext: chatLogExt, now: func() time.Time { return now }, onError: func(err error) {
+		t.Error(err)
+	}}
+	defer rw.Close()
+
+	rw.Write([]byte("a\n"))
+	rw.Write([]byte("b\n"))
+
+	now = now.Add(time.Minute)
+	rw.Write([]byte("c\n"))
+
+	first, err := os.ReadFile(filepath.Join(dir, "2022-07-19.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a\nb\n", string(first))
+
+	second, err := os.ReadFile(filepath.Join(dir, "2022-07-20.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, "c\n", string(second))
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	b := &bytes.Buffer{}
+	at := time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)
+
+	jsonLinesWriter{w: b, buffer: "#nako"}.Log("\x1b[1m11:53\x1b[0m gowon: hello", at)
+
+	assert.Equal(t, `{"time":"2022-07-19T11:53:00Z","buffer":"#nako","text":"11:53 gowon: hello"}`+"\n", b.String())
+}
+
+func TestChatLogLoggerFunc(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)
+
+	cl := createChatLog(dir, true, func(err error) {
+		t.Error(err)
+	})
+	cl.now = func() time.Time { return now }
+
+	l := createLogger(cl.LoggerFunc("#nako"), testClock())
+	l.Log("\x1b[31mhello\x1b[0m", now)
+	l.Log("replayed", now.Add(-time.Hour))
+	cl.Close()
+
+	plain, err := os.ReadFile(filepath.Join(dir, "#nako", "2022-07-19.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, "11:53 hello\n10:53 replayed\n", string(plain))
+
+	jsonLines, err := os.ReadFile(filepath.Join(dir, "#nako", "2022-07-19.jsonl"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(jsonLines), `"time":"2022-07-19T11:53:00Z","buffer":"#nako","text":"11:53 hello"`)
+	assert.Contains(t, string(jsonLines), `"time":"2022-07-19T10:53:00Z","buffer":"#nako","text":"10:53 replayed"`)
+}
+
+func TestChatLogCloseBuffer(t *testing.T) {
+	cl := createChatLog(t.TempDir(), true, func(err error) {
+		t.Error(err)
+	})
+	defer cl.Close()
+
+	cl.LoggerFunc("#nako")("a", time.Now())
+	cl.LoggerFunc("#gowon")("b", time.Now())
+
+	rws := cl.writers["#nako"]
+	require.Len(t, rws, 2)
+
+	cl.CloseBuffer("#nako")
+
+	for _, rw := range rws {
+		assert.Nil(t, rw.file)
+	}
+
+	assert.NotContains(t, cl.writers, "#nako")
+	assert.Contains(t, cl.writers, "#gowon")
+}
+
+func TestChatLogReportsFirstFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(dir, []byte{}, 0o600))
+
+	errs := make(chan error, 10)
+	cl := createChatLog(dir, false, func(err error) {
+		errs <- err
+	})
+	defer cl.Close()
+
+	f := cl.LoggerFunc("#nako")
+	f("a", time.Now())
+	f("b", time.Now())
+	cl.LoggerFunc("#gowon")("c", time.Now())
+
+	select {
+	case err := <-errs:
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("failure wasn't reported")
+	}
+
+	select {
+	case err := <-errs:
+		t.Errorf("reported again: %s", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReadChatLogTail(t *testing.T) {
+	dir := t.TempDir()
+	bufferDir := filepath.Join(dir, "#nako")
+	require.NoError(t, os.MkdirAll(bufferDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(bufferDir, "2022-07-18.log"), []byte("a\nb\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(bufferDir, "2022-07-19.log"), []byte("c\nd\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(bufferDir, "2022-07-19.jsonl"), []byte("{}\n"), 0o600))
+
+	globDir := filepath.Join(dir, "#[go]*?")
+	require.NoError(t, os.MkdirAll(globDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(globDir, "2022-07-19.log"), []byte("e\n"), 0o600))
+
+	cases := []struct {
+		name   string
+		buffer string
+		n      int
+		out    []string
+	}{
+		{
+			name:   "within latest file",
+			buffer: "#nako",
+			n:      1,
+			out:    []string{"d"},
+		},
+		{
+			name:   "across files",
+			buffer: "#nako",
+			n:      3,
+			out:    []string{"b", "c", "d"},
+		},
+		{
+			name:   "more than logged",
+			buffer: "#nako",
+			n:      10,
+			out:    []string{"a", "b", "c", "d"},
+		},
+		{
+			name:   "glob characters in name",
+			buffer: "#[go]*?",
+			n:      10,
+			out:    []string{"e"},
+		},
+		{
+			name:   "no logs",
+			buffer: "#gowon",
+			n:      10,
+			out:    []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readChatLogTail(dir, tc.buffer, tc.n)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
+
+func TestRestoreChatLog(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "#nako"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "#nako", "2022-07-19.log"), []byte("a\nb\n"), 0o600))
+
+	sb := createScrollback(func(buffer string) {})
+	sb.Append("#nako", "c", time.Now())
+
+	assert.Nil(t, restoreChatLog(dir, "#nako", 0, sb))
+	assert.Equal(t, []string{"c"}, sb.Lines("#nako"))
+
+	assert.Nil(t, restoreChatLog(dir, "#gowon", 10, sb))
+	assert.Equal(t, []string{}, sb.Lines("#gowon"))
+
+	assert.Nil(t, restoreChatLog(dir, "#nako", 10, sb))
+	assert.Equal(t, []string{"a", "b", restoredMarker, "c"}, sb.Lines("#nako"))
+}
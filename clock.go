@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	dayChangeFormat  = "Mon 02 Jan"
+	serverTimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// clock formats timestamps in the configured zone and layout
+type clock struct {
+	loc    *time.Location
+	layout string
+}
+
+func (c *clock) In(t time.Time) time.Time {
+	return t.In(c.loc)
+}
+
+func (c *clock) Format(t time.Time) string {
+	return c.In(t).Format(c.layout)
+}
+
+func (c *clock) Date(t time.Time) string {
+	return c.In(t).Format(chatLogDateFormat)
+}
+
+func createClock(zone, layout string) (*clock, error) {
+	if zone == "" {
+		zone = "Local"
+	}
+
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clock{loc: loc, layout: layout}, nil
+}
+
+// parseServerTime parses an IRCv3 server-time tag, accepting any RFC3339
+// timestamp with or without fractional seconds
+func parseServerTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339, strings.ToUpper(s))
+}
+
+func formatDayChange(t time.Time) string {
+	return "--- Day changed to " + t.Format(dayChangeFormat) + " ---"
+}
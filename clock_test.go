@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testClock() *clock {
+	return &clock{loc: time.UTC, layout: "15:04"}
+}
+
+func TestCreateClock(t *testing.T) {
+	ck, err := createClock("", "15:04")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Local, ck.loc)
+
+	ck, err = createClock("Asia/Tokyo", "Jan 02 15:04")
+	assert.Nil(t, err)
+	assert.Equal(t, "Jul 19 20:53", ck.Format(time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)))
+	assert.Equal(t, "2022-07-20", ck.Date(time.Date(2022, 7, 19, 23, 0, 0, 0, time.UTC)))
+
+	_, err = createClock("Nowhere/Nako", "15:04")
+	assert.NotNil(t, err)
+}
+
+func TestParseServerTime(t *testing.T) {
+	want := time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		in   string
+		out  time.Time
+		err  bool
+	}{
+		{
+			name: "milliseconds",
+			in:   "2022-07-19T11:53:00.000Z",
+			out:  want,
+		},
+		{
+			name: "no fractional seconds",
+			in:   "2022-07-19T11:53:00Z",
+			out:  want,
+		},
+		{
+			name: "nanoseconds",
+			in:   "2022-07-19T11:53:00.000000001Z",
+			out:  want.Add(time.Nanosecond),
+		},
+		{
+			name: "offset",
+			in:   "2022-07-19T12:53:00+01:00",
+			out:  want,
+		},
+		{
+			name: "lower case",
+			in:   "2022-07-19t11:53:00z",
+			out:  want,
+		},
+		{
+			name: "empty",
+			in:   "",
+			err:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseServerTime(tc.in)
+			if tc.err {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.True(t, tc.out.Equal(got))
+		})
+	}
+}
+
+func TestFormatDayChange(t *testing.T) {
+	assert.Equal(t, "--- Day changed to Mon 17 Oct ---", formatDayChange(time.Date(2022, 10, 17, 0, 0, 0, 0, time.UTC)))
+}
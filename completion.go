@@ -0,0 +1,203 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+const maxRecentSpeakers = 50
+
+var slashCommands = []string{
+	"ban",
+	"c",
+	"ch",
+	"chatlog",
+	"clear",
+	"deop",
+	"devoice",
+	"ignore",
+	"join",
+	"kick",
+	"me",
+	"mode",
+	"msg",
+	"n",
+	"names",
+	"op",
+	"part",
+	"query",
+	"quote",
+	"raw",
+	"t",
+	"topic",
+	"unban",
+	"unignore",
+	"voice",
+	"whois",
+	"whowas",
+}
+
+type recentSpeakers struct {
+	sync.Mutex
+	channels map[string][]string
+}
+
+func (rs *recentSpeakers) Spoke(channel, nick string) {
+	rs.Lock()
+	defer rs.Unlock()
+
+	speakers := []string{nick}
+
+	for _, s := range rs.channels[channel] {
+		if s != nick && len(speakers) < maxRecentSpeakers {
+			speakers = append(speakers, s)
+		}
+	}
+
+	rs.channels[channel] = speakers
+}
+
+func (rs *recentSpeakers) Recent(channel string) []string {
+	rs.Lock()
+	defer rs.Unlock()
+
+	return append([]string{}, rs.channels[channel]...)
+}
+
+func createRecentSpeakers() *recentSpeakers {
+	return &recentSpeakers{
+		channels: make(map[string][]string),
+	}
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func matchCompletions(word string, options []string, suffix string) []string {
+	matches := []string{}
+	seen := make(map[string]bool)
+
+	for _, o := range options {
+		if seen[o] || !hasPrefixFold(o, word) {
+			continue
+		}
+
+		seen[o] = true
+		matches = append(matches, o+suffix)
+	}
+
+	return matches
+}
+
+// completionCandidates returns the replacements for the word being completed.
+// Nicks are expected most recent speaker first.
+func completionCandidates(word string, lineStart bool, nicks, channels []string) []string {
+	if word == "" {
+		return []string{}
+	}
+
+	if strings.HasPrefix(word, "/") {
+		if !lineStart {
+			return []string{}
+		}
+
+		commands := matchCompletions(word[1:], slashCommands, " ")
+		for i := range commands {
+			commands[i] = "/" + commands[i]
+		}
+
+		return commands
+	}
+
+	if isChannel(word) {
+		sorted := append([]string{}, channels...)
+		sort.Strings(sorted)
+
+		return matchCompletions(word, sorted, " ")
+	}
+
+	if lineStart {
+		return matchCompletions(word, nicks, ": ")
+	}
+
+	return matchCompletions(word, nicks, " ")
+}
+
+type completion struct {
+	prefix     string
+	candidates []string
+	index      int
+}
+
+func (c completion) String() string {
+	return c.prefix + c.candidates[c.index]
+}
+
+type completer struct {
+	sync.Mutex
+	chans *channelSet
+	ml    *memberList
+	rs    *recentSpeakers
+	hl    *highlighter
+	last  map[string]completion
+}
+
+// Complete completes the last word of line, cycling through the candidates
+// while the line still holds the previous completion
+func (cp *completer) Complete(buffer, line string) (string, bool) {
+	cp.Lock()
+	defer cp.Unlock()
+
+	if c, p := cp.last[buffer]; p && c.String() == line {
+		c.index = (c.index + 1) % len(c.candidates)
+		cp.last[buffer] = c
+
+		return c.String(), true
+	}
+
+	delete(cp.last, buffer)
+
+	word := line[strings.LastIndex(line, " ")+1:]
+	prefix := line[:len(line)-len(word)]
+	own := cp.hl.Nick()
+	members := []string{}
+	nicks := []string{}
+
+	// our own nick is in the member list but never worth completing
+	for _, n := range cp.ml.Nicks(buffer) {
+		if !strings.EqualFold(n, own) {
+			members = append(members, n)
+		}
+	}
+
+	// recent speakers who have since left a channel aren't offered
+	for _, n := range cp.rs.Recent(buffer) {
+		if !isChannel(buffer) || containsString(members, n) {
+			nicks = append(nicks, n)
+		}
+	}
+
+	nicks = append(nicks, members...)
+
+	candidates := completionCandidates(word, prefix == "", nicks, cp.chans.List())
+	if len(candidates) == 0 {
+		return line, false
+	}
+
+	c := completion{prefix: prefix, candidates: candidates}
+	cp.last[buffer] = c
+
+	return c.String(), true
+}
+
+func createCompleter(chans *channelSet, ml *memberList, rs *recentSpeakers, hl *highlighter) *completer {
+	return &completer{
+		chans: chans,
+		ml:    ml,
+		rs:    rs,
+		hl:    hl,
+		last:  make(map[string]completion),
+	}
+}
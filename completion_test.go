@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentSpeakers(t *testing.T) {
+	rs := createRecentSpeakers()
+
+	rs.Spoke("#nako", "a")
+	rs.Spoke("#nako", "b")
+	rs.Spoke("#nako", "a")
+	rs.Spoke("#gowon", "c")
+
+	assert.Equal(t, []string{"a", "b"}, rs.Recent("#nako"))
+	assert.Equal(t, []string{"c"}, rs.Recent("#gowon"))
+	assert.Equal(t, []string{}, rs.Recent("#other"))
+}
+
+func TestCompletionCandidates(t *testing.T) {
+	nicks := []string{"gowon", "Gary", "nako", "gowon"}
+	channels := []string{"#nako", "#gowon", "#go"}
+
+	cases := []struct {
+		name      string
+		word      string
+		lineStart bool
+		out       []string
+	}{
+		{
+			name:      "empty word",
+			word:      "",
+			lineStart: true,
+			out:       []string{},
+		},
+		{
+			name:      "nick at line start",
+			word:      "g",
+			lineStart: true,
+			out:       []string{"gowon: ", "Gary: "},
+		},
+		{
+			name:      "nick mid line",
+			word:      "na",
+			lineStart: false,
+			out:       []string{"nako "},
+		},
+		{
+			name:      "channel",
+			word:      "#go",
+			lineStart: false,
+			out:       []string{"#go ", "#gowon "},
+		},
+		{
+			name:      "command",
+			word:      "/c",
+			lineStart: true,
+			out:       []string{"/c ", "/ch ", "/chatlog ", "/clear "},
+		},
+		{
+			name:      "command mid line",
+			word:      "/c",
+			lineStart: false,
+			out:       []string{},
+		},
+		{
+			name:      "no match",
+			word:      "x",
+			lineStart: true,
+			out:       []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, completionCandidates(tc.word, tc.lineStart, nicks, channels))
+		})
+	}
+}
+
+func TestCompleterComplete(t *testing.T) {
+	ml := createMemberList()
+	ml.Names("#nako", "gary gowon nako")
+	ml.EndNames("#nako")
+
+	rs := createRecentSpeakers()
+	rs.Spoke("#nako", "gowon")
+
+	cp := createCompleter(createChannelSet("#nako"), ml, rs, &highlighter{})
+
+	s, ok := cp.Complete("#nako", "g")
+	assert.True(t, ok)
+	assert.Equal(t, "gowon: ", s)
+
+	s, _ = cp.Complete("#nako", s)
+	assert.Equal(t, "gary: ", s)
+
+	s, _ = cp.Complete("#nako", s)
+	assert.Equal(t, "gowon: ", s)
+
+	s, _ = cp.Complete("#nako", "hello n")
+	assert.Equal(t, "hello nako ", s)
+
+	s, ok = cp.Complete("#nako", "hello x")
+	assert.False(t, ok)
+	assert.Equal(t, "hello x", s)
+
+	rs.Spoke("#nako", "gone")
+	ml.Part("#nako", "gowon")
+
+	s, _ = cp.Complete("#nako", "g")
+	assert.Equal(t, "gary: ", s)
+
+	s, _ = cp.Complete("#nako", s)
+	assert.Equal(t, "gary: ", s)
+
+	rs.Spoke("gowon", "gowon")
+
+	s, ok = cp.Complete("gowon", "g")
+	assert.True(t, ok)
+	assert.Equal(t, "gowon: ", s)
+}
+
+func TestCompleterSkipsOwnNick(t *testing.T) {
+	ml := createMemberList()
+	ml.Names("#nako", "@Nako naomi")
+	ml.EndNames("#nako")
+
+	hl := &highlighter{}
+	hl.SetNick("nako")
+
+	cp := createCompleter(createChannelSet("#nako"), ml, createRecentSpeakers(), hl)
+
+	s, ok := cp.Complete("#nako", "na")
+	assert.True(t, ok)
+	assert.Equal(t, "naomi: ", s)
+
+	s, _ = cp.Complete("#nako", s)
+	assert.Equal(t, "naomi: ", s)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+type highlightConfig struct {
+	Pattern    string `yaml:"pattern"`
+	Regex      bool   `yaml:"regex"`
+	Word       bool   `yaml:"word"`
+	IgnoreCase bool   `yaml:"ignore-case"`
+	Exclude    bool   `yaml:"exclude"`
+}
+
+type ignoreConfig struct {
+	Pattern string `yaml:"pattern"`
+	Channel string `yaml:"channel,omitempty"`
+}
+
+type channelConfig struct {
+	Highlights     []string          `yaml:"highlights"`
+	HighlightRules []highlightConfig `yaml:"highlight-rules"`
+	ShowJoins      *bool             `yaml:"show-joins"`
+	ShowQuits      *bool             `yaml:"show-quits"`
+	ShowKicks      *bool             `yaml:"show-kicks"`
+	ShowNicks      *bool             `yaml:"show-nicks"`
+	ShowModes      *bool             `yaml:"show-modes"`
+}
+
+type themeConfig struct {
+	Topic    string `yaml:"topic"`
+	Prompt   string `yaml:"prompt"`
+	Sidebar  string `yaml:"sidebar"`
+	NickList string `yaml:"nick-list"`
+}
+
+type config struct {
+	Options         `yaml:",inline"`
+	HighlightRules  []highlightConfig        `yaml:"highlight-rules"`
+	Ignores         []ignoreConfig           `yaml:"ignores"`
+	ChannelSettings map[string]channelConfig `yaml:"channel-settings"`
+	Keybindings     map[string]string        `yaml:"keybindings"`
+	Theme           themeConfig              `yaml:"theme"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "nako", "config.yaml")
+}
+
+// defaultStatePath returns a path under $XDG_STATE_HOME/nako, falling back to
+// ~/.local/state/nako
+func defaultStatePath(name string) string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+
+		dir = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(dir, "nako", name)
+}
+
+// explicitlySet reports whether an option was given on the command line or
+// through its environment variable, either of which override the config file
+func explicitlySet(o *flags.Option) bool {
+	if o.IsSet() && !o.IsSetDefault() {
+		return true
+	}
+
+	if o.EnvDefaultKey == "" {
+		return false
+	}
+
+	_, p := os.LookupEnv(o.EnvDefaultKey)
+
+	return p
+}
+
+func groupOptions(groups []*flags.Group) []*flags.Option {
+	options := []*flags.Option{}
+
+	for _, g := range groups {
+		options = append(options, g.Options()...)
+		options = append(options, groupOptions(g.Groups())...)
+	}
+
+	return options
+}
+
+func parseConfig(data []byte, opts Options, options []*flags.Option) (config, error) {
+	c := config{Options: opts}
+
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+
+	parsed := reflect.ValueOf(opts)
+	merged := reflect.ValueOf(&c.Options).Elem()
+
+	for _, o := range options {
+		if explicitlySet(o) {
+			name := o.Field().Name
+			merged.FieldByName(name).Set(parsed.FieldByName(name))
+		}
+	}
+
+	return c, nil
+}
+
+func configPath(opts Options) string {
+	if opts.Config == "" {
+		return defaultConfigPath()
+	}
+
+	return opts.Config
+}
+
+func loadConfig(opts Options, options []*flags.Option) (config, error) {
+	path := configPath(opts)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// a missing config file is only an error if one was asked for
+		if opts.Config == "" && errors.Is(err, fs.ErrNotExist) {
+			return config{Options: opts}, nil
+		}
+
+		return config{Options: opts}, err
+	}
+
+	c, err := parseConfig(data, opts, options)
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// saveConfigKey sets a single top level key in the config file, leaving the
+// rest of the file and its comments untouched
+func saveConfigKey(path, key string, value interface{}) error {
+	doc := yaml.Node{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: config is not a mapping", path)
+	}
+
+	encoded, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	valueDoc := yaml.Node{}
+	if err := yaml.Unmarshal(encoded, &valueDoc); err != nil {
+		return err
+	}
+
+	v := valueDoc.Content[0]
+
+	set := false
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i+1] = v
+			set = true
+		}
+	}
+
+	if !set {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+	}
+
+	// keep the two space indent config files are usually written with
+	out := bytes.Buffer{}
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, out.Bytes(), 0o600)
+}
+
+type channelSettings struct {
+	showEvents map[string]bool
+	channels   map[string]channelConfig
+}
+
+func (cs *channelSettings) ShowEvent(channel, code string) bool {
+	cc := cs.channels[channel]
+
+	overrides := map[string]*bool{
+		"JOIN": cc.ShowJoins,
+		"PART": cc.ShowJoins,
+		"QUIT": cc.ShowQuits,
+		"KICK": cc.ShowKicks,
+		"NICK": cc.ShowNicks,
+		"MODE": cc.ShowModes,
+	}
+
+	if o := overrides[code]; o != nil {
+		return *o
+	}
+
+	return cs.showEvents[code]
+}
+
+func createChannelSettings(c config) *channelSettings {
+	channels := c.ChannelSettings
+	if channels == nil {
+		channels = make(map[string]channelConfig)
+	}
+
+	return &channelSettings{
+		showEvents: eventFilters(c.Options),
+		channels:   channels,
+	}
+}
+
+var themeColours = map[string]gocui.Attribute{
+	"default": gocui.ColorDefault,
+	"black":   gocui.ColorBlack,
+	"red":     gocui.ColorRed,
+	"green":   gocui.ColorGreen,
+	"yellow":  gocui.ColorYellow,
+	"blue":    gocui.ColorBlue,
+	"magenta": gocui.ColorMagenta,
+	"cyan":    gocui.ColorCyan,
+	"white":   gocui.ColorWhite,
+}
+
+type theme struct {
+	topic    gocui.Attribute
+	prompt   gocui.Attribute
+	sidebar  gocui.Attribute
+	nickList gocui.Attribute
+}
+
+func themeColour(name string, fallback gocui.Attribute) (gocui.Attribute, error) {
+	if name == "" {
+		return fallback, nil
+	}
+
+	c, p := themeColours[strings.ToLower(name)]
+	if !p {
+		return fallback, fmt.Errorf("unknown theme colour %q", name)
+	}
+
+	return c, nil
+}
+
+func createTheme(tc themeConfig) (*theme, error) {
+	t := &theme{}
+
+	for _, c := range []struct {
+		name     string
+		dest     *gocui.Attribute
+		fallback gocui.Attribute
+	}{
+		{tc.Topic, &t.topic, gocui.ColorCyan},
+		{tc.Prompt, &t.prompt, gocui.ColorGreen},
+		{tc.Sidebar, &t.sidebar, gocui.ColorDefault},
+		{tc.NickList, &t.nickList, gocui.ColorDefault},
+	} {
+		colour, err := themeColour(c.name, c.fallback)
+		if err != nil {
+			return t, err
+		}
+
+		*c.dest = colour
+	}
+
+	return t, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseTestOptions(t *testing.T, args []string) (Options, []*flags.Option) {
+	opts := Options{}
+	parser := flags.NewParser(&opts, flags.Default)
+
+	_, err := parser.ParseArgs(args)
+	assert.Nil(t, err)
+
+	return opts, groupOptions(parser.Groups())
+}
+
+const testConfig = `
+broker: broker:1883
+channels: ["#nako", "#gowon"]
+show-joins: true
+channel-settings:
+  "#nako":
+    highlights: [gowon]
+    show-joins: false
+keybindings:
+  quit: ctrl+q
+theme:
+  topic: yellow
+`
+
+func TestParseConfig(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		broker   string
+		channels []string
+		root     string
+	}{
+		{
+			name:     "config overrides defaults",
+			args:     []string{},
+			broker:   "broker:1883",
+			channels: []string{"#nako", "#gowon"},
+			root:     "/gowon",
+		},
+		{
+			name:     "flags override config",
+			args:     []string{"-b", "flag:1883", "-c", "#flag"},
+			broker:   "flag:1883",
+			channels: []string{"#flag"},
+			root:     "/gowon",
+		},
+		{
+			name:     "env overrides config",
+			args:     []string{},
+			env:      map[string]string{"NAKO_BROKER": "env:1883"},
+			broker:   "env:1883",
+			channels: []string{"#nako", "#gowon"},
+			root:     "/gowon",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			opts, options := parseTestOptions(t, tc.args)
+			c, err := parseConfig([]byte(testConfig), opts, options)
+			assert.Nil(t, err)
+
+			assert.Equal(t, tc.broker, c.Broker)
+			assert.Equal(t, tc.channels, c.Channels)
+			assert.Equal(t, tc.root, c.TopicRoot)
+			assert.True(t, c.ShowJoins)
+			assert.Equal(t, "ctrl+q", c.Keybindings["quit"])
+			assert.Equal(t, "yellow", c.Theme.Topic)
+		})
+	}
+}
+
+func TestParseConfigInvalid(t *testing.T) {
+	opts, options := parseTestOptions(t, []string{})
+
+	_, err := parseConfig([]byte("channels: {"), opts, options)
+	assert.NotNil(t, err)
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	opts, options := parseTestOptions(t, []string{"-b", "flag:1883"})
+
+	c, err := loadConfig(opts, options)
+	assert.Nil(t, err)
+	assert.Equal(t, "flag:1883", c.Broker)
+
+	opts.Config = "/nonexistent/nako.yaml"
+	_, err = loadConfig(opts, options)
+	assert.NotNil(t, err)
+}
+
+func TestChannelSettings(t *testing.T) {
+	opts, options := parseTestOptions(t, []string{})
+	c, err := parseConfig([]byte(testConfig), opts, options)
+	assert.Nil(t, err)
+
+	cs := createChannelSettings(c)
+
+	assert.False(t, cs.ShowEvent("#nako", "JOIN"))
+	assert.True(t, cs.ShowEvent("#gowon", "JOIN"))
+	assert.False(t, cs.ShowEvent("#gowon", "QUIT"))
+}
+
+func TestCreateTheme(t *testing.T) {
+	th, err := createTheme(themeConfig{Topic: "Yellow"})
+	assert.Nil(t, err)
+	assert.Equal(t, gocui.ColorYellow, th.topic)
+	assert.Equal(t, gocui.ColorGreen, th.prompt)
+
+	_, err = createTheme(themeConfig{Prompt: "mauve"})
+	assert.NotNil(t, err)
+}
+
+func TestSaveConfigKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nako", "config.yaml")
+
+	assert.Nil(t, saveConfigKey(path, "ignores", []ignoreConfig{{Pattern: "gowon"}}))
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "ignores:\n  - pattern: gowon\n", string(data))
+
+	os.WriteFile(path, []byte("# broker\nbroker: broker:1883\nignores: []\n"), 0o600)
+	assert.Nil(t, saveConfigKey(path, "ignores", []ignoreConfig{{Pattern: "nako", Channel: "#nako"}}))
+
+	data, _ = os.ReadFile(path)
+	assert.Contains(t, string(data), "# broker\nbroker: broker:1883\n")
+
+	opts, options := parseTestOptions(t, []string{})
+	c, err := parseConfig(data, opts, options)
+	assert.Nil(t, err)
+	assert.Equal(t, []ignoreConfig{{Pattern: "nako", Channel: "#nako"}}, c.Ignores)
+
+	os.WriteFile(path, []byte("- a\n"), 0o600)
+	assert.NotNil(t, saveConfigKey(path, "ignores", []ignoreConfig{}))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const ctcpDelim = "\x01"
+
+func parseAction(msg string) (string, bool) {
+	if msg == ctcpDelim+"ACTION"+ctcpDelim {
+		return "", true
+	}
+
+	if !strings.HasPrefix(msg, ctcpDelim+"ACTION ") {
+		return "", false
+	}
+
+	action := strings.TrimPrefix(msg, ctcpDelim+"ACTION ")
+
+	return strings.TrimSuffix(action, ctcpDelim), true
+}
+
+func formatAction(text string) string {
+	return fmt.Sprintf("%sACTION %s%s", ctcpDelim, text, ctcpDelim)
+}
+
+// parseCTCP splits a CTCP message into its command and parameters
+func parseCTCP(msg string) (command, params string, ok bool) {
+	if !strings.HasPrefix(msg, ctcpDelim) {
+		return "", "", false
+	}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(msg, ctcpDelim), ctcpDelim)
+	command, params, _ = strings.Cut(body, " ")
+
+	return command, params, command != ""
+}
+
+// formatNoticeText shows CTCP replies, which arrive as notices, without their
+// delimiters
+func formatNoticeText(msg string) string {
+	command, params, ok := parseCTCP(msg)
+	if !ok {
+		return msg
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("CTCP %s reply: %s", strings.ToUpper(command), params))
+}
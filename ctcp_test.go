@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAction(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     string
+		out    string
+		action bool
+	}{
+		{
+			name:   "not an action",
+			in:     "hello",
+			out:    "",
+			action: false,
+		},
+		{
+			name:   "action",
+			in:     "\x01ACTION waves\x01",
+			out:    "waves",
+			action: true,
+		},
+		{
+			name:   "action without closing delimiter",
+			in:     "\x01ACTION waves",
+			out:    "waves",
+			action: true,
+		},
+		{
+			name:   "empty action",
+			in:     "\x01ACTION\x01",
+			out:    "",
+			action: true,
+		},
+		{
+			name:   "longer command",
+			in:     "\x01ACTIONfoo\x01",
+			out:    "",
+			action: false,
+		},
+		{
+			name:   "other ctcp",
+			in:     "\x01VERSION\x01",
+			out:    "",
+			action: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, action := parseAction(tc.in)
+			assert.Equal(t, tc.out, out)
+			assert.Equal(t, tc.action, action)
+		})
+	}
+}
+
+func TestFormatAction(t *testing.T) {
+	assert.Equal(t, "\x01ACTION waves\x01", formatAction("waves"))
+}
+
+func TestParseCTCP(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		command string
+		params  string
+		ok      bool
+	}{
+		{
+			name: "not ctcp",
+			in:   "hello",
+		},
+		{
+			name:    "reply with params",
+			in:      "\x01VERSION nako 1.0\x01",
+			command: "VERSION",
+			params:  "nako 1.0",
+			ok:      true,
+		},
+		{
+			name:    "no params",
+			in:      "\x01PING\x01",
+			command: "PING",
+			ok:      true,
+		},
+		{
+			name: "empty",
+			in:   "\x01\x01",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			command, params, ok := parseCTCP(tc.in)
+			assert.Equal(t, tc.command, command)
+			assert.Equal(t, tc.params, params)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestFormatNoticeText(t *testing.T) {
+	assert.Equal(t, "hello", formatNoticeText("hello"))
+	assert.Equal(t, "CTCP VERSION reply: nako 1.0", formatNoticeText("\x01VERSION nako 1.0\x01"))
+	assert.Equal(t, "CTCP PING reply: 1666000000", formatNoticeText("\x01ping 1666000000\x01"))
+	assert.Equal(t, "CTCP TIME reply:", formatNoticeText("\x01TIME\x01"))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func isChannel(target string) bool {
+	return target != "" && strings.ContainsRune("#&+!", rune(target[0]))
+}
+
+func eventFilters(opts Options) map[string]bool {
+	return map[string]bool{
+		"JOIN": opts.ShowJoins,
+		"PART": opts.ShowJoins,
+		"QUIT": opts.ShowQuits,
+		"KICK": opts.ShowKicks,
+		"NICK": opts.ShowNicks,
+		"MODE": opts.ShowModes,
+	}
+}
+
+func withReason(s, reason string) string {
+	if reason == "" {
+		return s
+	}
+
+	return fmt.Sprintf("%s (%s)", s, reason)
+}
+
+func formatJoin(nick, channel string) string {
+	return fmt.Sprintf("-> %s joined %s", nick, channel)
+}
+
+func formatPart(nick, channel, reason string) string {
+	return withReason(fmt.Sprintf("<- %s left %s", nick, channel), reason)
+}
+
+func formatQuit(nick, reason string) string {
+	return withReason(fmt.Sprintf("<- %s quit", nick), reason)
+}
+
+func formatKick(kicker, nick, channel, reason string) string {
+	return withReason(fmt.Sprintf("<- %s was kicked from %s by %s", nick, channel, kicker), reason)
+}
+
+func formatNick(oldNick, newNick string) string {
+	return fmt.Sprintf("%s is now known as %s", oldNick, newNick)
+}
+
+func formatMode(nick, target string, modes []string) string {
+	if nick == "" {
+		return fmt.Sprintf("mode %s [%s]", target, strings.Join(modes, " "))
+	}
+
+	return fmt.Sprintf("%s sets mode %s [%s]", nick, target, strings.Join(modes, " "))
+}
+
+// isServerSource reports whether a message came from a server rather than a
+// user, as servers have no user and usually a dotted name
+func isServerSource(nick, user string) bool {
+	return user == "" && (nick == "" || strings.Contains(nick, "."))
+}
+
+func formatNotice(from, text string) string {
+	if from == "" {
+		from = "server"
+	}
+
+	return fmt.Sprintf("-%s- %s", from, text)
+}
+
+func argOrEmpty(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+
+	return ""
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsChannel(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  bool
+	}{
+		{
+			name: "channel",
+			in:   "#nako",
+			out:  true,
+		},
+		{
+			name: "local channel",
+			in:   "&nako",
+			out:  true,
+		},
+		{
+			name: "nick",
+			in:   "nako",
+			out:  false,
+		},
+		{
+			name: "empty string",
+			in:   "",
+			out:  false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := isChannel(tc.in)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
+
+func TestEventFilters(t *testing.T) {
+	f := eventFilters(Options{ShowJoins: true, ShowModes: true})
+
+	assert.True(t, f["JOIN"])
+	assert.True(t, f["PART"])
+	assert.True(t, f["MODE"])
+	assert.False(t, f["QUIT"])
+	assert.False(t, f["KICK"])
+	assert.False(t, f["NICK"])
+	assert.False(t, f["PRIVMSG"])
+}
+
+func TestFormatEvents(t *testing.T) {
+	cases := []struct {
+		name string
+		got  string
+		out  string
+	}{
+		{
+			name: "join",
+			got:  formatJoin("nako", "#nako"),
+			out:  "-> nako joined #nako",
+		},
+		{
+			name: "part without reason",
+			got:  formatPart("nako", "#nako", ""),
+			out:  "<- nako left #nako",
+		},
+		{
+			name: "part with reason",
+			got:  formatPart("nako", "#nako", "bye"),
+			out:  "<- nako left #nako (bye)",
+		},
+		{
+			name: "quit",
+			got:  formatQuit("nako", "Ping timeout"),
+			out:  "<- nako quit (Ping timeout)",
+		},
+		{
+			name: "kick",
+			got:  formatKick("gowon", "nako", "#nako", "spam"),
+			out:  "<- nako was kicked from #nako by gowon (spam)",
+		},
+		{
+			name: "nick",
+			got:  formatNick("nako", "nako_"),
+			out:  "nako is now known as nako_",
+		},
+		{
+			name: "channel mode",
+			got:  formatMode("gowon", "#nako", []string{"+o", "nako"}),
+			out:  "gowon sets mode #nako [+o nako]",
+		},
+		{
+			name: "server mode",
+			got:  formatMode("", "nako", []string{"+i"}),
+			out:  "mode nako [+i]",
+		},
+		{
+			name: "user notice",
+			got:  formatNotice("NickServ", "You are now identified"),
+			out:  "-NickServ- You are now identified",
+		},
+		{
+			name: "server notice",
+			got:  formatNotice("", "Looking up your hostname"),
+			out:  "-server- Looking up your hostname",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, tc.got)
+		})
+	}
+}
+
+func TestIsServerSource(t *testing.T) {
+	assert.True(t, isServerSource("irc.example.com", ""))
+	assert.True(t, isServerSource("", ""))
+	assert.False(t, isServerSource("NickServ", "NickServ"))
+	assert.False(t, isServerSource("gowon", ""))
+}
+
+func TestArgOrEmpty(t *testing.T) {
+	args := []string{"#nako", "bye"}
+
+	assert.Equal(t, "bye", argOrEmpty(args, 1))
+	assert.Equal(t, "", argOrEmpty(args, 2))
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	ircBold          = '\x02'
+	ircColour        = '\x03'
+	ircHexColour     = '\x04'
+	ircReset         = '\x0f'
+	ircMonospace     = '\x11'
+	ircReverse       = '\x16'
+	ircItalic        = '\x1d'
+	ircStrikethrough = '\x1e'
+	ircUnderline     = '\x1f'
+)
+
+const ircDefaultColour = 99
+
+// ansiBasicColours maps the 16 standard irc colours onto the 8 ansi colours
+var ansiBasicColours = [16]int{
+	7, // white
+	0, // black
+	4, // navy blue -> blue
+	2, // green
+	1, // red
+	3, // brown -> yellow
+	5, // purple -> magenta
+	2, // olive -> green
+	3, // yellow
+	2, // lime green -> green
+	6, // teal -> cyan
+	6, // aqua blue -> cyan
+	4, // royal blue -> blue
+	1, // hot pink -> red
+	0, // dark grey -> black
+	0, // light grey -> black
+}
+
+// ansiExtendedColours maps irc colours 16-98 onto the xterm 256 colour palette
+var ansiExtendedColours = [83]int{
+	52, 94, 100, 58, 22, 29, 23, 24, 17, 54, 53, 89,
+	88, 130, 142, 64, 28, 35, 30, 25, 18, 91, 90, 125,
+	124, 166, 184, 106, 34, 49, 37, 33, 19, 129, 127, 161,
+	196, 208, 226, 154, 46, 86, 51, 75, 21, 171, 201, 198,
+	203, 215, 227, 191, 83, 122, 87, 111, 63, 177, 207, 205,
+	217, 223, 229, 193, 157, 158, 159, 153, 147, 183, 219, 212,
+	16, 233, 235, 237, 239, 241, 244, 247, 250, 254, 231,
+}
+
+// ircColourParams returns the ansi sgr parameters for an irc colour, base
+// being 30 for foreground colours and 40 for background colours
+func ircColourParams(colour, base int) string {
+	if colour < len(ansiBasicColours) {
+		return strconv.Itoa(base + ansiBasicColours[colour])
+	}
+
+	if colour < ircDefaultColour {
+		return fmt.Sprintf("%d;5;%d", base+8, ansiExtendedColours[colour-len(ansiBasicColours)])
+	}
+
+	return ""
+}
+
+func hexColourParams(hex string, base int) string {
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%d;2;%d;%d;%d", base+8, rgb>>16, (rgb>>8)&0xff, rgb&0xff)
+}
+
+type ircFormat struct {
+	bold          bool
+	italic        bool
+	underline     bool
+	strikethrough bool
+	reverse       bool
+	fg            string
+	bg            string
+}
+
+func (f ircFormat) effects() []string {
+	effects := []string{}
+
+	for _, e := range []struct {
+		on     bool
+		params string
+	}{
+		{f.bold, "1"},
+		{f.italic, "3"},
+		{f.underline, "4"},
+		{f.reverse, "7"},
+		{f.strikethrough, "9"},
+	} {
+		if e.on {
+			effects = append(effects, e.params)
+		}
+	}
+
+	return effects
+}
+
+func (f ircFormat) params() []string {
+	params := []string{}
+
+	if f.fg != "" {
+		params = append(params, f.fg)
+	}
+
+	if f.bg != "" {
+		params = append(params, f.bg)
+	}
+
+	return append(params, f.effects()...)
+}
+
+func (f ircFormat) removes(next ircFormat) bool {
+	return (f.bold && !next.bold) ||
+		(f.italic && !next.italic) ||
+		(f.underline && !next.underline) ||
+		(f.strikethrough && !next.strikethrough) ||
+		(f.reverse && !next.reverse) ||
+		(f.fg != "" && next.fg == "") ||
+		(f.bg != "" && next.bg == "")
+}
+
+func sgr(params []string) string {
+	var sb strings.Builder
+
+	for _, p := range params {
+		fmt.Fprintf(&sb, "\x1b[%sm", p)
+	}
+
+	return sb.String()
+}
+
+// transition returns the ansi sequences needed to move from one format to the
+// next. Colour sequences reset text effects in some terminals, so effects are
+// reapplied after any colour change.
+func (f ircFormat) transition(next ircFormat) string {
+	if f == next {
+		return ""
+	}
+
+	if f.removes(next) {
+		return sgr([]string{"0"}) + sgr(next.params())
+	}
+
+	params := []string{}
+	colourChanged := false
+
+	if f.fg != next.fg {
+		params = append(params, next.fg)
+		colourChanged = true
+	}
+
+	if f.bg != next.bg {
+		params = append(params, next.bg)
+		colourChanged = true
+	}
+
+	if colourChanged {
+		return sgr(append(params, next.effects()...))
+	}
+
+	prevEffects := map[string]bool{}
+	for _, e := range f.effects() {
+		prevEffects[e] = true
+	}
+
+	for _, e := range next.effects() {
+		if !prevEffects[e] {
+			params = append(params, e)
+		}
+	}
+
+	return sgr(params)
+}
+
+func readDigits(rs []rune, i, max int) (string, int) {
+	j := i
+	for j < len(rs) && j-i < max && rs[j] >= '0' && rs[j] <= '9' {
+		j++
+	}
+
+	return string(rs[i:j]), j
+}
+
+func readHex(rs []rune, i int) (string, int) {
+	if i+6 > len(rs) {
+		return "", i
+	}
+
+	h := string(rs[i : i+6])
+	if _, err := strconv.ParseUint(h, 16, 32); err != nil {
+		return "", i
+	}
+
+	return h, i + 6
+}
+
+func ircToAnsiColours(s string) string {
+	var sb strings.Builder
+
+	rs := []rune(s)
+	current := ircFormat{}
+
+	for i := 0; i < len(rs); i++ {
+		next := current
+
+		switch rs[i] {
+		case ircBold:
+			next.bold = !next.bold
+		case ircItalic:
+			next.italic = !next.italic
+		case ircUnderline:
+			next.underline = !next.underline
+		case ircStrikethrough:
+			next.strikethrough = !next.strikethrough
+		case ircReverse:
+			next.reverse = !next.reverse
+		case ircReset:
+			next = ircFormat{}
+		case ircMonospace:
+		case ircColour:
+			fg, j := readDigits(rs, i+1, 2)
+			if fg == "" {
+				next.fg, next.bg = "", ""
+				break
+			}
+
+			fgColour, _ := strconv.Atoi(fg)
+			next.fg = ircColourParams(fgColour, 30)
+
+			if j+1 < len(rs) && rs[j] == ',' {
+				if bg, k := readDigits(rs, j+1, 2); bg != "" {
+					bgColour, _ := strconv.Atoi(bg)
+					next.bg = ircColourParams(bgColour, 40)
+					j = k
+				}
+			}
+
+			i = j - 1
+		case ircHexColour:
+			fg, j := readHex(rs, i+1)
+			if fg == "" {
+				next.fg, next.bg = "", ""
+				break
+			}
+
+			next.fg = hexColourParams(fg, 30)
+
+			if j+1 < len(rs) && rs[j] == ',' {
+				if bg, k := readHex(rs, j+1); bg != "" {
+					next.bg = hexColourParams(bg, 40)
+					j = k
+				}
+			}
+
+			i = j - 1
+		default:
+			sb.WriteRune(rs[i])
+			continue
+		}
+
+		sb.WriteString(current.transition(next))
+		current = next
+	}
+
+	return sb.String()
+}
+
+const ircFormattingCodes = "\x02\x03\x04\x0f\x11\x16\x1d\x1e\x1f"
+
+func hasIrcFormatting(s string) bool {
+	return strings.ContainsAny(s, ircFormattingCodes)
+}
+
+var markupCodes = map[rune]rune{
+	'*': ircBold,
+	'_': ircItalic,
+}
+
+func isMarkupBoundary(rs []rune, i int) bool {
+	return i < 0 || i >= len(rs) || !(unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]))
+}
+
+func findMarkupClose(rs []rune, open int) int {
+	for j := open + 2; j < len(rs); j++ {
+		if rs[j] == rs[open] && !unicode.IsSpace(rs[j-1]) && isMarkupBoundary(rs, j+1) {
+			return j
+		}
+	}
+
+	return -1
+}
+
+// convertMarkup turns *bold* and _italic_ markup into irc formatting codes.
+// Markers only count at word boundaries so snake_case and 2*3*4 are left alone.
+func convertMarkup(s string) string {
+	rs := []rune(s)
+
+	for i := 0; i < len(rs)-2; i++ {
+		code, p := markupCodes[rs[i]]
+		if !p || !isMarkupBoundary(rs, i-1) || unicode.IsSpace(rs[i+1]) {
+			continue
+		}
+
+		j := findMarkupClose(rs, i)
+		if j < 0 {
+			continue
+		}
+
+		rs[i], rs[j] = code, code
+		i = j
+	}
+
+	return string(rs)
+}
+
+func formatPalette() string {
+	var sb strings.Builder
+
+	for i := range ansiBasicColours {
+		fmt.Fprintf(&sb, "%c%02d%02d ", ircColour, i, i)
+	}
+
+	return ircToAnsiColours(sb.String() + string(ircReset))
+}
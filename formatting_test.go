@@ -0,0 +1,213 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIrcToAnsiColours(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "empty string",
+			in:   "",
+			out:  "",
+		},
+		{
+			name: "no colours",
+			in:   "nako",
+			out:  "nako",
+		},
+		{
+			name: "one colour",
+			in:   "\u000301nako",
+			out:  "\x1b[30mnako",
+		},
+		{
+			name: "colour and reset",
+			in:   "\u000301nako\u000399",
+			out:  "\x1b[30mnako\x1b[0m",
+		},
+		{
+			name: "single digit colour",
+			in:   "\u00034nako",
+			out:  "\x1b[31mnako",
+		},
+		{
+			name: "single digit colour followed by digits",
+			in:   "\u0003041nako",
+			out:  "\x1b[31m1nako",
+		},
+		{
+			name: "foreground and background",
+			in:   "\u000304,02nako",
+			out:  "\x1b[31m\x1b[44mnako",
+		},
+		{
+			name: "comma without background",
+			in:   "\u000304,nako",
+			out:  "\x1b[31m,nako",
+		},
+		{
+			name: "bare colour code resets colours",
+			in:   "\u000304nako\u0003 gowon",
+			out:  "\x1b[31mnako\x1b[0m gowon",
+		},
+		{
+			name: "extended colour",
+			in:   "\u000352nako",
+			out:  "\x1b[38;5;196mnako",
+		},
+		{
+			name: "extended background",
+			in:   "\u000300,98nako",
+			out:  "\x1b[37m\x1b[48;5;231mnako",
+		},
+		{
+			name: "hex colour",
+			in:   "\u0004FF8000nako",
+			out:  "\x1b[38;2;255;128;0mnako",
+		},
+		{
+			name: "hex foreground and background",
+			in:   "\u0004FF8000,000000nako",
+			out:  "\x1b[38;2;255;128;0m\x1b[48;2;0;0;0mnako",
+		},
+		{
+			name: "invalid hex resets colours",
+			in:   "\u000304nako\u0004zz",
+			out:  "\x1b[31mnako\x1b[0mzz",
+		},
+		{
+			name: "bold",
+			in:   "\u0002nako\u0002 gowon",
+			out:  "\x1b[1mnako\x1b[0m gowon",
+		},
+		{
+			name: "italic, underline, strikethrough and reverse",
+			in:   "\u001d\u001f\u001e\u0016nako",
+			out:  "\x1b[3m\x1b[4m\x1b[9m\x1b[7mnako",
+		},
+		{
+			name: "turning off one effect keeps the others",
+			in:   "\u0002\u001dnako\u0002 gowon",
+			out:  "\x1b[1m\x1b[3mnako\x1b[0m\x1b[3m gowon",
+		},
+		{
+			name: "colour change reapplies effects",
+			in:   "\u0002nako\u000304 gowon",
+			out:  "\x1b[1mnako\x1b[31m\x1b[1m gowon",
+		},
+		{
+			name: "reset",
+			in:   "\u0002\u000304nako\u000f gowon",
+			out:  "\x1b[1m\x1b[31m\x1b[1mnako\x1b[0m gowon",
+		},
+		{
+			name: "reset with nothing set",
+			in:   "nako\u000f",
+			out:  "nako",
+		},
+		{
+			name: "monospace is stripped",
+			in:   "\u0011nako",
+			out:  "nako",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ircToAnsiColours(tc.in)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
+
+func TestHasIrcFormatting(t *testing.T) {
+	assert.False(t, hasIrcFormatting("nako"))
+	assert.True(t, hasIrcFormatting("\u0002nako"))
+	assert.True(t, hasIrcFormatting("\u000304nako"))
+}
+
+func TestConvertMarkup(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "no markup",
+			in:   "nako",
+			out:  "nako",
+		},
+		{
+			name: "bold",
+			in:   "hello *nako*",
+			out:  "hello \u0002nako\u0002",
+		},
+		{
+			name: "italic",
+			in:   "_hello_ nako",
+			out:  "\u001dhello\u001d nako",
+		},
+		{
+			name: "multiple words",
+			in:   "*hello there* nako",
+			out:  "\u0002hello there\u0002 nako",
+		},
+		{
+			name: "adjacent markup",
+			in:   "*hello* _nako_",
+			out:  "\u0002hello\u0002 \u001dnako\u001d",
+		},
+		{
+			name: "followed by punctuation",
+			in:   "hello *nako*!",
+			out:  "hello \u0002nako\u0002!",
+		},
+		{
+			name: "snake case",
+			in:   "snake_case_words",
+			out:  "snake_case_words",
+		},
+		{
+			name: "multiplication",
+			in:   "2*3*4",
+			out:  "2*3*4",
+		},
+		{
+			name: "unclosed",
+			in:   "*nako",
+			out:  "*nako",
+		},
+		{
+			name: "spaced markers",
+			in:   "a * b * c",
+			out:  "a * b * c",
+		},
+		{
+			name: "empty markers",
+			in:   "**",
+			out:  "**",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := convertMarkup(tc.in)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
+
+func TestFormatPalette(t *testing.T) {
+	got := formatPalette()
+
+	assert.Contains(t, got, "\x1b[37m00 ")
+	assert.Contains(t, got, "\x1b[30m01 ")
+	assert.Contains(t, got, "15 \x1b[0m")
+}
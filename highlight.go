@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	ansiReverse    = "\x1b[7m"
+	ansiReverseOff = "\x1b[27m"
+)
+
+type highlightRule struct {
+	re      *regexp.Regexp
+	exclude bool
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// boundedLiteral quotes a literal for a regex, only requiring word boundaries
+// next to word characters so nicks like "nako|away" still match
+func boundedLiteral(s string) string {
+	pattern := regexp.QuoteMeta(s)
+
+	if r, _ := utf8.DecodeRuneInString(s); isWordRune(r) {
+		pattern = `\b` + pattern
+	}
+
+	if r, _ := utf8.DecodeLastRuneInString(s); isWordRune(r) {
+		pattern = pattern + `\b`
+	}
+
+	return pattern
+}
+
+func compileHighlightRule(hc highlightConfig) (highlightRule, error) {
+	if hc.Pattern == "" {
+		return highlightRule{}, fmt.Errorf("highlight rule has no pattern")
+	}
+
+	pattern := regexp.QuoteMeta(hc.Pattern)
+
+	if hc.Regex && hc.Word {
+		pattern = `\b(?:` + hc.Pattern + `)\b`
+	} else if hc.Regex {
+		pattern = hc.Pattern
+	} else if hc.Word {
+		pattern = boundedLiteral(hc.Pattern)
+	}
+
+	if hc.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return highlightRule{}, fmt.Errorf("highlight rule %q: %w", hc.Pattern, err)
+	}
+
+	return highlightRule{re: re, exclude: hc.Exclude}, nil
+}
+
+func compileHighlightRules(words []string, hcs []highlightConfig) ([]highlightRule, error) {
+	rules := []highlightRule{}
+	all := []highlightConfig{}
+
+	for _, w := range words {
+		all = append(all, highlightConfig{Pattern: w})
+	}
+
+	for _, hc := range append(all, hcs...) {
+		rule, err := compileHighlightRule(hc)
+		if err != nil {
+			return rules, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+type highlighter struct {
+	sync.Mutex
+	global   []highlightRule
+	channels map[string][]highlightRule
+	nick     string
+	nickRule highlightRule
+}
+
+func (h *highlighter) SetNick(nick string) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.nick = nick
+	h.nickRule = highlightRule{}
+
+	if nick != "" {
+		h.nickRule.re = regexp.MustCompile("(?i)" + boundedLiteral(nick))
+	}
+}
+
+func (h *highlighter) Nick() string {
+	h.Lock()
+	defer h.Unlock()
+
+	return h.nick
+}
+
+func (h *highlighter) rules(channel string) []highlightRule {
+	h.Lock()
+	defer h.Unlock()
+
+	rules := append([]highlightRule{}, h.global...)
+	rules = append(rules, h.channels[channel]...)
+
+	if h.nickRule.re != nil {
+		rules = append(rules, h.nickRule)
+	}
+
+	return rules
+}
+
+// Match returns the spans of text to highlight in a channel, or none if an
+// exclude rule matches
+func (h *highlighter) Match(channel, text string) [][]int {
+	spans := [][]int{}
+
+	for _, r := range h.rules(channel) {
+		matches := r.re.FindAllStringIndex(text, -1)
+
+		if r.exclude && len(matches) > 0 {
+			return [][]int{}
+		}
+
+		if !r.exclude {
+			spans = append(spans, matches...)
+		}
+	}
+
+	return mergeSpans(spans)
+}
+
+func mergeSpans(spans [][]int) [][]int {
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i][0] < spans[j][0]
+	})
+
+	merged := [][]int{}
+
+	for _, s := range spans {
+		if s[0] == s[1] {
+			continue
+		}
+
+		if n := len(merged); n > 0 && s[0] <= merged[n-1][1] {
+			if s[1] > merged[n-1][1] {
+				merged[n-1][1] = s[1]
+			}
+			continue
+		}
+
+		merged = append(merged, []int{s[0], s[1]})
+	}
+
+	return merged
+}
+
+// highlightSpans reverses the colours of each span, the spans being offsets
+// into s with its ansi sequences removed. Reverse is reapplied after any
+// sequence inside a span, and spans are closed with SGR 27, which
+// closeHighlights replaces once the line's ansi is final.
+func highlightSpans(s string, spans [][]int) string {
+	var sb strings.Builder
+	seqs := sgrRegex.FindAllStringIndex(s, -1)
+	pos, open := 0, false
+
+	for i := 0; i < len(s); {
+		if len(seqs) > 0 && seqs[0][0] == i {
+			sb.WriteString(s[i:seqs[0][1]])
+			if open {
+				sb.WriteString(ansiReverse)
+			}
+
+			i = seqs[0][1]
+			seqs = seqs[1:]
+			continue
+		}
+
+		if !open && len(spans) > 0 && pos == spans[0][0] {
+			sb.WriteString(ansiReverse)
+			open = true
+		}
+
+		sb.WriteByte(s[i])
+		i++
+		pos++
+
+		if open && pos == spans[0][1] {
+			sb.WriteString(ansiReverseOff)
+			open = false
+			spans = spans[1:]
+		}
+	}
+
+	return sb.String()
+}
+
+// Highlight converts a message's irc formatting to ansi and highlights it,
+// matching against the text without formatting so codes inside or next to a
+// word don't stop it matching
+func (h *highlighter) Highlight(channel, text string) (string, bool) {
+	s := ircToAnsiColours(text)
+	spans := h.Match(channel, sgrRegex.ReplaceAllString(s, ""))
+
+	return highlightSpans(s, spans), len(spans) > 0
+}
+
+var sgrRegex = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
+// closeHighlights ends each highlight with a full reset followed by the
+// sequences still active on the line, as gocui ignores SGR 27
+func closeHighlights(s string) string {
+	var sb strings.Builder
+	active := []string{}
+	last := 0
+
+	for _, loc := range sgrRegex.FindAllStringSubmatchIndex(s, -1) {
+		sb.WriteString(s[last:loc[0]])
+		last = loc[1]
+
+		seq, params := s[loc[0]:loc[1]], s[loc[2]:loc[3]]
+
+		switch {
+		case seq == ansiReverseOff:
+			for i := len(active) - 1; i >= 0; i-- {
+				if active[i] == ansiReverse {
+					active = append(active[:i], active[i+1:]...)
+					break
+				}
+			}
+
+			sb.WriteString("\x1b[0m" + strings.Join(active, ""))
+			continue
+		case params == "" || params == "0":
+			active = []string{}
+		case strings.HasPrefix(params, "0;"):
+			active = []string{seq}
+		default:
+			active = append(active, seq)
+		}
+
+		sb.WriteString(seq)
+	}
+
+	sb.WriteString(s[last:])
+
+	return sb.String()
+}
+
+func createHighlighter(c config) (*highlighter, error) {
+	global, err := compileHighlightRules(c.Highlights, c.HighlightRules)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &highlighter{
+		global:   global,
+		channels: make(map[string][]highlightRule),
+	}
+
+	for channel, cc := range c.ChannelSettings {
+		rules, err := compileHighlightRules(cc.Highlights, cc.HighlightRules)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", channel, err)
+		}
+
+		h.channels[channel] = rules
+	}
+
+	h.SetNick(c.Nick)
+
+	return h, nil
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/logrusorgru/aurora"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoundedLiteral(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "word",
+			in:   "nako",
+			out:  `\bnako\b`,
+		},
+		{
+			name: "trailing symbol",
+			in:   "nako|",
+			out:  `\bnako\|`,
+		},
+		{
+			name: "leading symbol",
+			in:   "[nako",
+			out:  `\[nako\b`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, boundedLiteral(tc.in))
+		})
+	}
+}
+
+func TestCompileHighlightRule(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      highlightConfig
+		text    string
+		matches [][]int
+	}{
+		{
+			name:    "literal",
+			in:      highlightConfig{Pattern: "a.c"},
+			text:    "abc a.c",
+			matches: [][]int{{4, 7}},
+		},
+		{
+			name:    "case sensitive by default",
+			in:      highlightConfig{Pattern: "nako"},
+			text:    "Nako nako",
+			matches: [][]int{{5, 9}},
+		},
+		{
+			name:    "ignore case",
+			in:      highlightConfig{Pattern: "nako", IgnoreCase: true},
+			text:    "Nako nako",
+			matches: [][]int{{0, 4}, {5, 9}},
+		},
+		{
+			name:    "word",
+			in:      highlightConfig{Pattern: "nako", Word: true},
+			text:    "nakos nako",
+			matches: [][]int{{6, 10}},
+		},
+		{
+			name:    "regex",
+			in:      highlightConfig{Pattern: "go+d", Regex: true},
+			text:    "gd good",
+			matches: [][]int{{3, 7}},
+		},
+		{
+			name:    "regex word",
+			in:      highlightConfig{Pattern: "go+d", Regex: true, Word: true},
+			text:    "goods good",
+			matches: [][]int{{6, 10}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := compileHighlightRule(tc.in)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.matches, rule.re.FindAllStringIndex(tc.text, -1))
+		})
+	}
+}
+
+func TestCompileHighlightRuleInvalid(t *testing.T) {
+	_, err := compileHighlightRule(highlightConfig{})
+	assert.NotNil(t, err)
+
+	_, err = compileHighlightRule(highlightConfig{Pattern: "(", Regex: true})
+	assert.NotNil(t, err)
+}
+
+func TestMergeSpans(t *testing.T) {
+	cases := []struct {
+		name string
+		in   [][]int
+		out  [][]int
+	}{
+		{
+			name: "empty",
+			in:   [][]int{},
+			out:  [][]int{},
+		},
+		{
+			name: "unordered",
+			in:   [][]int{{5, 6}, {0, 1}},
+			out:  [][]int{{0, 1}, {5, 6}},
+		},
+		{
+			name: "overlapping",
+			in:   [][]int{{0, 4}, {2, 6}, {3, 5}},
+			out:  [][]int{{0, 6}},
+		},
+		{
+			name: "empty span",
+			in:   [][]int{{2, 2}},
+			out:  [][]int{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, mergeSpans(tc.in))
+		})
+	}
+}
+
+func TestHighlightSpans(t *testing.T) {
+	assert.Equal(t, "hi \x1b[7mnako\x1b[27m!", highlightSpans("hi nako!", [][]int{{3, 7}}))
+	assert.Equal(t, "hi", highlightSpans("hi", [][]int{}))
+	assert.Equal(t, "\x1b[31m\x1b[7mna\x1b[0m\x1b[7mko\x1b[27m:", highlightSpans("\x1b[31mna\x1b[0mko:", [][]int{{0, 4}}))
+}
+
+func TestHighlighterHighlight(t *testing.T) {
+	h, err := createHighlighter(config{Options: Options{Nick: "nako"}})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		in          string
+		out         string
+		highlighted bool
+	}{
+		{
+			name:        "plain",
+			in:          "hi nako",
+			out:         "hi \x1b[7mnako\x1b[27m",
+			highlighted: true,
+		},
+		{
+			name:        "coloured nick",
+			in:          "\x0304nako\x03: hi",
+			out:         "\x1b[31m\x1b[7mnako\x1b[27m\x1b[0m: hi",
+			highlighted: true,
+		},
+		{
+			name:        "reset inside nick",
+			in:          "\x02na\x0fko",
+			out:         "\x1b[1m\x1b[7mna\x1b[0m\x1b[7mko\x1b[27m",
+			highlighted: true,
+		},
+		{
+			name: "colour code digits",
+			in:   "\x03044 nakos",
+			out:  "\x1b[31m4 nakos",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, highlighted := h.Highlight("#nako", tc.in)
+			assert.Equal(t, tc.out, out)
+			assert.Equal(t, tc.highlighted, highlighted)
+		})
+	}
+}
+
+func TestHighlighter(t *testing.T) {
+	c := config{
+		Options: Options{
+			Highlights: []string{"gowon"},
+			Nick:       "nako",
+		},
+		HighlightRules: []highlightConfig{
+			{Pattern: "^\\[bot\\]", Regex: true, Exclude: true},
+		},
+		ChannelSettings: map[string]channelConfig{
+			"#nako": {
+				HighlightRules: []highlightConfig{{Pattern: "deploy", Word: true, IgnoreCase: true}},
+			},
+		},
+	}
+
+	h, err := createHighlighter(c)
+	assert.Nil(t, err)
+
+	assert.Equal(t, [][]int{{0, 4}, {5, 10}}, h.Match("#gowon", "NAKO gowon"))
+	assert.Equal(t, [][]int{}, h.Match("#gowon", "Deploy now"))
+	assert.Equal(t, [][]int{{0, 6}}, h.Match("#nako", "Deploy now"))
+	assert.Equal(t, [][]int{}, h.Match("#nako", "[bot] nako deploy"))
+
+	h.SetNick("kaon")
+	assert.Equal(t, "kaon", h.Nick())
+	assert.Equal(t, [][]int{}, h.Match("#gowon", "nako"))
+	assert.Equal(t, [][]int{{0, 4}}, h.Match("#gowon", "kaon"))
+
+	c.ChannelSettings["#nako"] = channelConfig{HighlightRules: []highlightConfig{{Pattern: "(", Regex: true}}}
+	_, err = createHighlighter(c)
+	assert.NotNil(t, err)
+}
+
+func TestCloseHighlights(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "no highlights",
+			in:   "\x1b[38;5;3mgowon: hi\x1b[0m",
+			out:  "\x1b[38;5;3mgowon: hi\x1b[0m",
+		},
+		{
+			name: "nick colour restored",
+			in:   "\x1b[38;5;3mgowon: hi \x1b[7mnako\x1b[27m!\x1b[0m",
+			out:  "\x1b[38;5;3mgowon: hi \x1b[7mnako\x1b[0m\x1b[38;5;3m!\x1b[0m",
+		},
+		{
+			name: "formatting before the span restored",
+			in:   "\x1b[38;5;3mgowon: \x1b[1mhi \x1b[7mnako\x1b[27m!\x1b[0m",
+			out:  "\x1b[38;5;3mgowon: \x1b[1mhi \x1b[7mnako\x1b[0m\x1b[38;5;3m\x1b[1m!\x1b[0m",
+		},
+		{
+			name: "reset inside the span",
+			in:   "\x1b[38;5;3mgowon: \x1b[7mna\x1b[0m\x1b[32mko\x1b[27m!",
+			out:  "\x1b[38;5;3mgowon: \x1b[7mna\x1b[0m\x1b[32mko\x1b[0m\x1b[32m!",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, closeHighlights(tc.in))
+		})
+	}
+}
+
+// renderAttributes writes s to a gocui view and returns the foreground
+// attributes of each cell on the first line. The simulator skips escape
+// parsing, so the view is created in the output mode nako runs in.
+func renderAttributes(t *testing.T, s string) []gocui.Attribute {
+	g, err := gocui.NewGui(gocui.OutputSimulator, true)
+	require.NoError(t, err)
+	defer g.Close()
+
+	mode := reflect.ValueOf(g).Elem().FieldByName("outputMode")
+	reflect.NewAt(mode.Type(), unsafe.Pointer(mode.UnsafeAddr())).Elem().Set(reflect.ValueOf(gocui.OutputTrue))
+
+	v, err := g.SetView("test", 0, 0, 80, 2, 0)
+	if err != nil && err != gocui.ErrUnknownView {
+		require.NoError(t, err)
+	}
+
+	fmt.Fprint(v, s)
+
+	cells := reflect.ValueOf(v).Elem().FieldByName("lines").Index(0)
+	attrs := []gocui.Attribute{}
+
+	for i := 0; i < cells.Len(); i++ {
+		attrs = append(attrs, gocui.Attribute(cells.Index(i).FieldByName("fgColor").Uint()))
+	}
+
+	return attrs
+}
+
+func TestHighlightRendering(t *testing.T) {
+	text := "hi nako!"
+	out := aurora.Index(3, "gowon: "+highlightSpans(text, [][]int{{3, 7}})).String()
+	attrs := renderAttributes(t, closeHighlights(out))
+
+	require.Len(t, attrs, len("gowon: hi nako!"))
+
+	for i, a := range attrs {
+		reversed := i >= len("gowon: hi ") && i < len("gowon: hi nako")
+		assert.Equal(t, reversed, a&gocui.AttrReverse != 0, "cell %d", i)
+		assert.Equal(t, attrs[0]&^gocui.AttrReverse, a&^gocui.AttrReverse, "cell %d", i)
+	}
+}
+
+func TestColouredNickHighlightRendering(t *testing.T) {
+	h, err := createHighlighter(config{Options: Options{Nick: "nako"}})
+	require.NoError(t, err)
+
+	text, _ := h.Highlight("#nako", "\x0304nako\x03: hi")
+	attrs := renderAttributes(t, closeHighlights(aurora.Index(3, "gowon: "+text).String()))
+
+	require.Len(t, attrs, len("gowon: nako: hi"))
+
+	for i, a := range attrs {
+		reversed := i >= len("gowon: ") && i < len("gowon: nako")
+		assert.Equal(t, reversed, a&gocui.AttrReverse != 0, "cell %d", i)
+	}
+}
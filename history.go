@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type historySearch struct {
+	query string
+	pos   int
+}
+
+type inputHistory struct {
+	sync.Mutex
+	entries map[string][]string
+	pos     map[string]int
+	drafts  map[string]string
+	search  map[string]historySearch
+	size    int
+	path    string
+}
+
+func (h *inputHistory) Add(buffer, line string) {
+	h.Lock()
+	defer h.Unlock()
+
+	delete(h.pos, buffer)
+	delete(h.search, buffer)
+
+	entries := h.entries[buffer]
+	if len(entries) > 0 && entries[len(entries)-1] == line {
+		return
+	}
+
+	entries = append(entries, line)
+	if len(entries) > h.size {
+		entries = entries[len(entries)-h.size:]
+	}
+
+	h.entries[buffer] = entries
+}
+
+// navigating reports whether the entry view still shows the history entry
+// last recalled, so edited lines start a fresh walk through the history
+func (h *inputHistory) navigating(buffer, current string) (int, bool) {
+	entries := h.entries[buffer]
+	pos, p := h.pos[buffer]
+
+	return pos, p && pos < len(entries) && entries[pos] == current
+}
+
+func (h *inputHistory) Prev(buffer, current string) (string, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	entries := h.entries[buffer]
+
+	pos, ok := h.navigating(buffer, current)
+	if !ok {
+		pos = len(entries)
+		h.drafts[buffer] = current
+	}
+
+	if pos == 0 {
+		return current, false
+	}
+
+	h.pos[buffer] = pos - 1
+
+	return entries[pos-1], true
+}
+
+func (h *inputHistory) Next(buffer, current string) (string, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	entries := h.entries[buffer]
+
+	pos, ok := h.navigating(buffer, current)
+	if !ok {
+		return current, false
+	}
+
+	if pos+1 == len(entries) {
+		delete(h.pos, buffer)
+		return h.drafts[buffer], true
+	}
+
+	h.pos[buffer] = pos + 1
+
+	return entries[pos+1], true
+}
+
+func (h *inputHistory) Search(buffer, current string) (string, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	entries := h.entries[buffer]
+	start, query := len(entries), current
+
+	if s, p := h.search[buffer]; p && s.pos < len(entries) && entries[s.pos] == current {
+		start, query = s.pos, s.query
+	}
+
+	for i := start - 1; i >= 0; i-- {
+		if strings.Contains(entries[i], query) {
+			h.search[buffer] = historySearch{query: query, pos: i}
+			return entries[i], true
+		}
+	}
+
+	return current, false
+}
+
+func (h *inputHistory) Save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	h.Lock()
+	data, err := json.Marshal(h.entries)
+	h.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(h.path, data, 0o600)
+}
+
+func (h *inputHistory) load() error {
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		return err
+	}
+
+	for buffer, entries := range h.entries {
+		if len(entries) > h.size {
+			h.entries[buffer] = entries[len(entries)-h.size:]
+		}
+	}
+
+	return nil
+}
+
+func createInputHistory(path string, size int) (*inputHistory, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("history size can't be negative, got %d", size)
+	}
+
+	h := &inputHistory{
+		entries: make(map[string][]string),
+		pos:     make(map[string]int),
+		drafts:  make(map[string]string),
+		search:  make(map[string]historySearch),
+		size:    size,
+		path:    path,
+	}
+
+	if path == "" {
+		return h, nil
+	}
+
+	return h, h.load()
+}
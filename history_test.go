@@ -0,0 +1,144 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testInputHistory(lines ...string) *inputHistory {
+	h, _ := createInputHistory("", 3)
+
+	for _, l := range lines {
+		h.Add("#nako", l)
+	}
+
+	return h
+}
+
+func TestInputHistoryAdd(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines []string
+		out   []string
+	}{
+		{
+			name:  "single line",
+			lines: []string{"hello"},
+			out:   []string{"hello"},
+		},
+		{
+			name:  "consecutive duplicates",
+			lines: []string{"hello", "hello", "world", "hello"},
+			out:   []string{"hello", "world", "hello"},
+		},
+		{
+			name:  "ring size",
+			lines: []string{"a", "b", "c", "d"},
+			out:   []string{"b", "c", "d"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, testInputHistory(tc.lines...).entries["#nako"])
+		})
+	}
+}
+
+func TestInputHistoryPrevNext(t *testing.T) {
+	h := testInputHistory("a", "b")
+
+	s, ok := h.Next("#nako", "draft")
+	assert.False(t, ok)
+	assert.Equal(t, "draft", s)
+
+	s, _ = h.Prev("#nako", "draft")
+	assert.Equal(t, "b", s)
+
+	s, _ = h.Prev("#nako", s)
+	assert.Equal(t, "a", s)
+
+	s, ok = h.Prev("#nako", s)
+	assert.False(t, ok)
+	assert.Equal(t, "a", s)
+
+	s, _ = h.Next("#nako", s)
+	assert.Equal(t, "b", s)
+
+	s, ok = h.Next("#nako", s)
+	assert.True(t, ok)
+	assert.Equal(t, "draft", s)
+
+	s, ok = h.Prev("#other", "")
+	assert.False(t, ok)
+	assert.Equal(t, "", s)
+}
+
+func TestInputHistoryPrevAfterEdit(t *testing.T) {
+	h := testInputHistory("a", "b")
+
+	s, _ := h.Prev("#nako", "")
+	assert.Equal(t, "b", s)
+
+	s, _ = h.Prev("#nako", "b edited")
+	assert.Equal(t, "b", s)
+
+	s, _ = h.Next("#nako", s)
+	assert.Equal(t, "b edited", s)
+}
+
+func TestInputHistorySearch(t *testing.T) {
+	h := testInputHistory(".weather london", ".np", ".weather paris")
+
+	s, _ := h.Search("#nako", "weather")
+	assert.Equal(t, ".weather paris", s)
+
+	s, _ = h.Search("#nako", s)
+	assert.Equal(t, ".weather london", s)
+
+	s, ok := h.Search("#nako", s)
+	assert.False(t, ok)
+	assert.Equal(t, ".weather london", s)
+
+	s, ok = h.Search("#nako", "nothing")
+	assert.False(t, ok)
+	assert.Equal(t, "nothing", s)
+}
+
+func TestInputHistoryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nako", "history.json")
+
+	h, err := createInputHistory(path, 10)
+	assert.Nil(t, err)
+
+	h.Add("#nako", "hello")
+	h.Add("#gowon", ".np")
+	assert.Nil(t, h.Save())
+
+	loaded, err := createInputHistory(path, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, h.entries, loaded.entries)
+}
+
+func TestInputHistoryLoadTrims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	h, err := createInputHistory(path, 10)
+	assert.Nil(t, err)
+
+	h.Add("#nako", "one")
+	h.Add("#nako", "two")
+	h.Add("#nako", "three")
+	assert.Nil(t, h.Save())
+
+	loaded, err := createInputHistory(path, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"two", "three"}, loaded.entries["#nako"])
+}
+
+func TestInputHistoryNegativeSize(t *testing.T) {
+	_, err := createInputHistory("", -1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type ignoreKind int
+
+const (
+	ignoreNick ignoreKind = iota
+	ignoreMask
+	ignoreMessage
+)
+
+type ignoreRule struct {
+	ignoreConfig
+	kind ignoreKind
+	re   *regexp.Regexp
+}
+
+// globRegex turns an irc style glob, where * and ? match any characters, into
+// a case-insensitive regex matching the whole string
+func globRegex(glob string) (*regexp.Regexp, error) {
+	pattern := regexp.QuoteMeta(glob)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+
+	return regexp.Compile("(?i)^" + pattern + "$")
+}
+
+// compileIgnoreRule reads /regex/ as a message pattern, anything containing !
+// or @ as a nick!user@host glob and everything else as a nick glob
+func compileIgnoreRule(ic ignoreConfig) (ignoreRule, error) {
+	p := ic.Pattern
+	rule := ignoreRule{ignoreConfig: ic}
+
+	var err error
+
+	switch {
+	case p == "":
+		return rule, fmt.Errorf("ignore rule has no pattern")
+	case len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/"):
+		rule.kind = ignoreMessage
+		rule.re, err = regexp.Compile(p[1 : len(p)-1])
+	case strings.ContainsAny(p, "!@"):
+		rule.kind = ignoreMask
+		rule.re, err = globRegex(p)
+	default:
+		rule.kind = ignoreNick
+		rule.re, err = globRegex(p)
+	}
+
+	if err != nil {
+		return rule, fmt.Errorf("ignore rule %q: %w", p, err)
+	}
+
+	return rule, nil
+}
+
+func (r ignoreRule) matches(channel, nick, mask, text string) bool {
+	if r.Channel != "" && !strings.EqualFold(r.Channel, channel) {
+		return false
+	}
+
+	switch r.kind {
+	case ignoreMask:
+		return r.re.MatchString(mask)
+	case ignoreMessage:
+		return text != "" && r.re.MatchString(text)
+	default:
+		return r.re.MatchString(nick)
+	}
+}
+
+type ignoreList struct {
+	sync.Mutex
+	rules []ignoreRule
+	save  func(ics []ignoreConfig) error
+}
+
+func ignoreConfigs(rules []ignoreRule) []ignoreConfig {
+	ics := []ignoreConfig{}
+	for _, r := range rules {
+		ics = append(ics, r.ignoreConfig)
+	}
+
+	return ics
+}
+
+func (il *ignoreList) List() []ignoreConfig {
+	il.Lock()
+	defer il.Unlock()
+
+	return ignoreConfigs(il.rules)
+}
+
+// errIgnoresNotSaved is returned when a change applies but couldn't be
+// written to the config, like when it's read-only
+var errIgnoresNotSaved = errors.New("ignores kept for this session only, saving failed")
+
+// replace uses the new rules straight away, then saves them
+func (il *ignoreList) replace(rules []ignoreRule) error {
+	il.rules = rules
+
+	if err := il.save(ignoreConfigs(rules)); err != nil {
+		return fmt.Errorf("%w: %s", errIgnoresNotSaved, err)
+	}
+
+	return nil
+}
+
+func (il *ignoreList) Add(ic ignoreConfig) error {
+	il.Lock()
+	defer il.Unlock()
+
+	for _, r := range il.rules {
+		if r.ignoreConfig == ic {
+			return fmt.Errorf("already ignoring %s", formatIgnore(ic))
+		}
+	}
+
+	rule, err := compileIgnoreRule(ic)
+	if err != nil {
+		return err
+	}
+
+	return il.replace(append(append([]ignoreRule{}, il.rules...), rule))
+}
+
+func (il *ignoreList) Remove(ic ignoreConfig) error {
+	il.Lock()
+	defer il.Unlock()
+
+	for i, r := range il.rules {
+		if r.ignoreConfig == ic {
+			rules := append(append([]ignoreRule{}, il.rules[:i]...), il.rules[i+1:]...)
+			return il.replace(rules)
+		}
+	}
+
+	return fmt.Errorf("not ignoring %s", formatIgnore(ic))
+}
+
+// Ignored reports whether a message or event from nick!user@host in a channel
+// matches any rule. Text is empty for events without a message.
+func (il *ignoreList) Ignored(channel, nick, user, host, text string) bool {
+	il.Lock()
+	defer il.Unlock()
+
+	mask := fmt.Sprintf("%s!%s@%s", nick, user, host)
+
+	for _, r := range il.rules {
+		if r.matches(channel, nick, mask, text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func createIgnoreList(ics []ignoreConfig, save func(ics []ignoreConfig) error) (*ignoreList, error) {
+	il := &ignoreList{save: save}
+
+	for _, ic := range ics {
+		rule, err := compileIgnoreRule(ic)
+		if err != nil {
+			return nil, err
+		}
+
+		il.rules = append(il.rules, rule)
+	}
+
+	return il, nil
+}
+
+// parseIgnoreArgs reads "pattern [#channel]" from an /ignore or /unignore
+// command, keeping spaces inside message patterns
+func parseIgnoreArgs(text string) ignoreConfig {
+	fields := strings.Fields(text)
+	if len(fields) < 2 || !isChannel(fields[len(fields)-1]) {
+		return ignoreConfig{Pattern: text}
+	}
+
+	channel := fields[len(fields)-1]
+	pattern := strings.TrimSpace(strings.TrimSuffix(text, channel))
+
+	return ignoreConfig{Pattern: pattern, Channel: channel}
+}
+
+func formatIgnore(ic ignoreConfig) string {
+	if ic.Channel == "" {
+		return ic.Pattern
+	}
+
+	return fmt.Sprintf("%s in %s", ic.Pattern, ic.Channel)
+}
+
+func runIgnoreCommand(command, text string, il *ignoreList, l *logger) {
+	if text == "" && command == "ignore" {
+		ics := il.List()
+		if len(ics) == 0 {
+			l.Log("not ignoring anyone")
+		}
+
+		for _, ic := range ics {
+			l.Log("ignoring " + formatIgnore(ic))
+		}
+
+		return
+	}
+
+	if text == "" {
+		l.Log("usage: /unignore <pattern> [channel]")
+		return
+	}
+
+	ic := parseIgnoreArgs(text)
+
+	if command == "ignore" {
+		err := il.Add(ic)
+		if err != nil && !errors.Is(err, errIgnoresNotSaved) {
+			l.Log(err.Error())
+			return
+		}
+
+		l.Log("ignoring " + formatIgnore(ic))
+		if err != nil {
+			l.Log(err.Error())
+		}
+
+		return
+	}
+
+	err := il.Remove(ic)
+	if err != nil && !errors.Is(err, errIgnoresNotSaved) {
+		l.Log(err.Error())
+		return
+	}
+
+	l.Log("no longer ignoring " + formatIgnore(ic))
+	if err != nil {
+		l.Log(err.Error())
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobRegex(t *testing.T) {
+	cases := []struct {
+		name  string
+		glob  string
+		in    string
+		match bool
+	}{
+		{
+			name:  "exact",
+			glob:  "nako",
+			in:    "NAKO",
+			match: true,
+		},
+		{
+			name:  "star",
+			glob:  "*!*@bots.example",
+			in:    "gowon!bot@bots.example",
+			match: true,
+		},
+		{
+			name:  "star across slashes",
+			glob:  "*!*@user/*",
+			in:    "nako!n@user/nako",
+			match: true,
+		},
+		{
+			name:  "question mark",
+			glob:  "nak?",
+			in:    "nako",
+			match: true,
+		},
+		{
+			name:  "whole string",
+			glob:  "nako",
+			in:    "nakos",
+			match: false,
+		},
+		{
+			name:  "regex characters are literal",
+			glob:  "n.ko",
+			in:    "nako",
+			match: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			re, err := globRegex(tc.glob)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.match, re.MatchString(tc.in))
+		})
+	}
+}
+
+func TestCompileIgnoreRule(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		kind ignoreKind
+		err  bool
+	}{
+		{
+			name: "nick",
+			in:   "gowon",
+			kind: ignoreNick,
+		},
+		{
+			name: "mask",
+			in:   "*!*@bots.example",
+			kind: ignoreMask,
+		},
+		{
+			name: "message",
+			in:   "/^!spam/",
+			kind: ignoreMessage,
+		},
+		{
+			name: "lone slash is a nick",
+			in:   "/",
+			kind: ignoreNick,
+		},
+		{
+			name: "invalid regex",
+			in:   "/(/",
+			err:  true,
+		},
+		{
+			name: "empty",
+			in:   "",
+			err:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := compileIgnoreRule(ignoreConfig{Pattern: tc.in})
+			if tc.err {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.kind, rule.kind)
+		})
+	}
+}
+
+func TestIgnoreList(t *testing.T) {
+	saved := [][]ignoreConfig{}
+	save := func(ics []ignoreConfig) error {
+		saved = append(saved, ics)
+		return nil
+	}
+
+	il, err := createIgnoreList([]ignoreConfig{{Pattern: "gowon"}}, save)
+	assert.Nil(t, err)
+
+	assert.True(t, il.Ignored("#nako", "Gowon", "bot", "bots.example", "hello"))
+	assert.False(t, il.Ignored("#nako", "nako", "n", "example.com", "hello"))
+
+	assert.Nil(t, il.Add(ignoreConfig{Pattern: "*!*@spam.example", Channel: "#nako"}))
+	assert.True(t, il.Ignored("#nako", "spammer", "s", "spam.example", ""))
+	assert.False(t, il.Ignored("#gowon", "spammer", "s", "spam.example", ""))
+
+	assert.Nil(t, il.Add(ignoreConfig{Pattern: "/^!spam/"}))
+	assert.True(t, il.Ignored("#gowon", "nako", "n", "example.com", "!spam now"))
+	assert.False(t, il.Ignored("#gowon", "nako", "n", "example.com", ""))
+
+	assert.NotNil(t, il.Add(ignoreConfig{Pattern: "gowon"}))
+	assert.NotNil(t, il.Remove(ignoreConfig{Pattern: "nako"}))
+
+	assert.Nil(t, il.Remove(ignoreConfig{Pattern: "gowon"}))
+	assert.False(t, il.Ignored("#nako", "gowon", "bot", "bots.example", "hello"))
+
+	assert.Equal(t, []ignoreConfig{
+		{Pattern: "*!*@spam.example", Channel: "#nako"},
+		{Pattern: "/^!spam/"},
+	}, il.List())
+	assert.Len(t, saved, 3)
+	assert.Equal(t, il.List(), saved[2])
+
+	_, err = createIgnoreList([]ignoreConfig{{Pattern: "/(/"}}, save)
+	assert.NotNil(t, err)
+}
+
+func TestIgnoreListSaveFailure(t *testing.T) {
+	save := func(ics []ignoreConfig) error {
+		return errors.New("read-only config")
+	}
+
+	il, err := createIgnoreList([]ignoreConfig{{Pattern: "gowon"}}, save)
+	assert.Nil(t, err)
+
+	assert.ErrorIs(t, il.Add(ignoreConfig{Pattern: "nako"}), errIgnoresNotSaved)
+	assert.Equal(t, []ignoreConfig{{Pattern: "gowon"}, {Pattern: "nako"}}, il.List())
+	assert.True(t, il.Ignored("#nako", "nako", "", "", ""))
+
+	assert.ErrorIs(t, il.Remove(ignoreConfig{Pattern: "gowon"}), errIgnoresNotSaved)
+	assert.Equal(t, []ignoreConfig{{Pattern: "nako"}}, il.List())
+	assert.False(t, il.Ignored("#nako", "gowon", "", "", ""))
+
+	err = il.Add(ignoreConfig{Pattern: "nako"})
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, errIgnoresNotSaved)
+}
+
+func TestRunIgnoreCommandSaveFailure(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := createLogger(genWriterLoggerFunc(b), testClock())
+
+	il, err := createIgnoreList(nil, func(ics []ignoreConfig) error {
+		return errors.New("read-only config")
+	})
+	assert.Nil(t, err)
+
+	runIgnoreCommand("ignore", "gowon", il, l)
+
+	out := stripFormatting(b.String())
+	assert.Contains(t, out, "ignoring gowon\n")
+	assert.Contains(t, out, "ignores kept for this session only, saving failed: read-only config\n")
+	assert.True(t, il.Ignored("#nako", "gowon", "", "", ""))
+}
+
+func TestParseIgnoreArgs(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  ignoreConfig
+	}{
+		{
+			name: "pattern",
+			in:   "gowon",
+			out:  ignoreConfig{Pattern: "gowon"},
+		},
+		{
+			name: "pattern and channel",
+			in:   "gowon #nako",
+			out:  ignoreConfig{Pattern: "gowon", Channel: "#nako"},
+		},
+		{
+			name: "regex with spaces",
+			in:   "/spam here/ #nako",
+			out:  ignoreConfig{Pattern: "/spam here/", Channel: "#nako"},
+		},
+		{
+			name: "channel only",
+			in:   "#nako",
+			out:  ignoreConfig{Pattern: "#nako"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, parseIgnoreArgs(tc.in))
+		})
+	}
+}
+
+func TestRunIgnoreCommand(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := createLogger(genWriterLoggerFunc(b), testClock())
+
+	il, _ := createIgnoreList([]ignoreConfig{}, func(ics []ignoreConfig) error {
+		return nil
+	})
+
+	runIgnoreCommand("ignore", "", il, l)
+	runIgnoreCommand("ignore", "gowon #nako", il, l)
+	runIgnoreCommand("ignore", "", il, l)
+	runIgnoreCommand("unignore", "gowon #nako", il, l)
+	runIgnoreCommand("unignore", "gowon #nako", il, l)
+	runIgnoreCommand("unignore", "", il, l)
+
+	out := stripFormatting(b.String())
+	assert.Contains(t, out, "not ignoring anyone")
+	assert.Contains(t, out, "ignoring gowon in #nako")
+	assert.Contains(t, out, "no longer ignoring gowon in #nako")
+	assert.Contains(t, out, "not ignoring gowon in #nako")
+	assert.Contains(t, out, "usage: /unignore")
+
+	il.save = func(ics []ignoreConfig) error {
+		return errors.New("read-only")
+	}
+
+	b.Reset()
+	runIgnoreCommand("ignore", "nako", il, l)
+	assert.Contains(t, b.String(), "read-only")
+}
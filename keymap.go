@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+const (
+	scopeGlobal = ""
+	scopeEntry  = "entry"
+)
+
+type keyAction struct {
+	scope   string
+	handler func(g *gocui.Gui, v *gocui.View) error
+}
+
+type keybinding struct {
+	key interface{}
+	mod gocui.Modifier
+}
+
+var namedKeys = map[string]gocui.Key{
+	"enter":      gocui.KeyEnter,
+	"tab":        gocui.KeyTab,
+	"backtab":    gocui.KeyBacktab,
+	"esc":        gocui.KeyEsc,
+	"space":      gocui.KeySpace,
+	"backspace":  gocui.KeyBackspace2,
+	"delete":     gocui.KeyDelete,
+	"insert":     gocui.KeyInsert,
+	"home":       gocui.KeyHome,
+	"end":        gocui.KeyEnd,
+	"pgup":       gocui.KeyPgup,
+	"pgdn":       gocui.KeyPgdn,
+	"up":         gocui.KeyArrowUp,
+	"down":       gocui.KeyArrowDown,
+	"left":       gocui.KeyArrowLeft,
+	"right":      gocui.KeyArrowRight,
+	"ctrl+[":     gocui.KeyCtrlLsqBracket,
+	"ctrl+]":     gocui.KeyCtrlRsqBracket,
+	"ctrl+\\":    gocui.KeyCtrlBackslash,
+	"ctrl+_":     gocui.KeyCtrlUnderscore,
+	"ctrl+space": gocui.KeyCtrlSpace,
+}
+
+func init() {
+	for i := 0; i < 26; i++ {
+		namedKeys[fmt.Sprintf("ctrl+%c", 'a'+i)] = gocui.KeyCtrlA + gocui.Key(i)
+	}
+
+	for i := 0; i < 12; i++ {
+		namedKeys[fmt.Sprintf("f%d", i+1)] = gocui.KeyF1 + gocui.Key(i)
+	}
+
+	for _, preset := range keymapPresets {
+		for i := 1; i <= 10; i++ {
+			preset[fmt.Sprintf("buffer-%d", i)] = fmt.Sprintf("alt+%d", i%10)
+		}
+	}
+}
+
+func parseKey(s string) (keybinding, error) {
+	s = strings.TrimSpace(s)
+	mod := gocui.ModNone
+
+	if rs := []rune(s); len(rs) == 1 {
+		return keybinding{key: rs[0], mod: mod}, nil
+	}
+
+	if strings.HasPrefix(strings.ToLower(s), "alt+") {
+		s = s[len("alt+"):]
+		mod = gocui.ModAlt
+
+		if rs := []rune(s); len(rs) == 1 {
+			return keybinding{key: rs[0], mod: mod}, nil
+		}
+	}
+
+	k, p := namedKeys[strings.ToLower(s)]
+	if !p {
+		return keybinding{}, fmt.Errorf("unknown key %q", s)
+	}
+
+	return keybinding{key: k, mod: mod}, nil
+}
+
+func parseKeys(s string) ([]keybinding, error) {
+	kbs := []keybinding{}
+
+	for _, k := range strings.Split(s, ",") {
+		if strings.TrimSpace(k) == "" {
+			continue
+		}
+
+		kb, err := parseKey(k)
+		if err != nil {
+			return kbs, err
+		}
+
+		kbs = append(kbs, kb)
+	}
+
+	return kbs, nil
+}
+
+var keymapPresets = map[string]map[string]string{
+	"vi": {
+		"quit":             "ctrl+c",
+		"send":             "enter",
+		"entry-clear":      "ctrl+u",
+		"focus-entry":      "tab,i",
+		"focus-chat":       "esc",
+		"scroll-down":      "j",
+		"scroll-up":        "k",
+		"scroll-page-down": "J,pgdn",
+		"scroll-page-up":   "K,pgup",
+		"buffer-next":      "ctrl+n",
+		"buffer-prev":      "ctrl+p",
+		"toggle-names":     "f2",
+		"format-bold":      "ctrl+b",
+		"format-italic":    "ctrl+]",
+		"format-underline": "ctrl+_",
+		"format-reset":     "ctrl+o",
+		"format-colour":    "ctrl+k",
+		"history-prev":     "up",
+		"history-next":     "down",
+		"history-search":   "ctrl+r",
+		"complete":         "tab",
+	},
+	"emacs": {
+		"quit":             "ctrl+c",
+		"send":             "enter",
+		"entry-clear":      "ctrl+u",
+		"focus-entry":      "tab",
+		"focus-chat":       "esc",
+		"scroll-down":      "alt+n",
+		"scroll-up":        "alt+p",
+		"scroll-page-down": "ctrl+v,pgdn",
+		"scroll-page-up":   "alt+v,pgup",
+		"buffer-next":      "ctrl+x",
+		"buffer-prev":      "alt+x",
+		"toggle-names":     "f2",
+		"format-bold":      "ctrl+b",
+		"format-italic":    "ctrl+]",
+		"format-underline": "ctrl+_",
+		"format-reset":     "ctrl+o",
+		"format-colour":    "ctrl+k",
+		"history-prev":     "up",
+		"history-next":     "down",
+		"history-search":   "ctrl+r",
+		"complete":         "tab",
+	},
+}
+
+type keymap map[string][]keybinding
+
+// bindingViews returns the views a binding is set on. Global bindings on
+// modified characters are ignored while typing unless also bound to the
+// editable entry view.
+func bindingViews(a keyAction, kb keybinding) []string {
+	if _, isRune := kb.key.(rune); isRune && kb.mod != gocui.ModNone && a.scope == scopeGlobal {
+		return []string{scopeGlobal, scopeEntry}
+	}
+
+	return []string{a.scope}
+}
+
+func createKeymap(preset string, overrides map[string]string, actions map[string]keyAction) (keymap, error) {
+	bindings, p := keymapPresets[preset]
+	if !p {
+		return nil, fmt.Errorf("unknown keymap preset %q", preset)
+	}
+
+	merged := make(map[string]string)
+	for action, keys := range bindings {
+		merged[action] = keys
+	}
+
+	for action, keys := range overrides {
+		merged[action] = keys
+	}
+
+	km := make(keymap)
+	bound := make(map[string]string)
+
+	for action, keys := range merged {
+		a, p := actions[action]
+		if !p {
+			return nil, fmt.Errorf("unknown keybinding action %q", action)
+		}
+
+		kbs, err := parseKeys(keys)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", action, err)
+		}
+
+		for _, kb := range kbs {
+			// plain characters would swallow typing in the entry view
+			if _, isRune := kb.key.(rune); isRune && kb.mod == gocui.ModNone && a.scope == scopeEntry {
+				return nil, fmt.Errorf("%s: %q would capture typing in the entry view", action, kb.key)
+			}
+
+			for _, view := range bindingViews(a, kb) {
+				id := fmt.Sprintf("%s/%v/%d", view, kb.key, kb.mod)
+				if other, p := bound[id]; p {
+					return nil, fmt.Errorf("%s: %q conflicts with %s", action, keys, other)
+				}
+				bound[id] = action
+			}
+		}
+
+		km[action] = kbs
+	}
+
+	return km, nil
+}
+
+func (km keymap) Apply(g *gocui.Gui, actions map[string]keyAction) error {
+	names := make([]string, 0, len(km))
+	for action := range km {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+
+	for _, action := range names {
+		a := actions[action]
+
+		for _, kb := range km[action] {
+			for _, view := range bindingViews(a, kb) {
+				if err := g.SetKeybinding(view, kb.key, kb.mod, a.handler); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func createActions(bl *bufferList, h *inputHistory, cp *completer, showNames, showPalette *toggle, sendMessage func(g *gocui.Gui, v *gocui.View) error, requestHistory func(buffer string)) map[string]keyAction {
+	actions := map[string]keyAction{
+		"quit":             {scopeGlobal, quit},
+		"send":             {scopeEntry, sendMessage},
+		"entry-clear":      {scopeEntry, entryClear},
+		"focus-entry":      {scopeGlobal, entrySwitch},
+		"focus-chat":       {scopeEntry, genChatSwitch(bl)},
+		"scroll-down":      {scopeGlobal, genScrollActive(bl, 1, requestHistory)},
+		"scroll-up":        {scopeGlobal, genScrollActive(bl, -1, requestHistory)},
+		"scroll-page-down": {scopeGlobal, genScrollActive(bl, 10, requestHistory)},
+		"scroll-page-up":   {scopeGlobal, genScrollActive(bl, -10, requestHistory)},
+		"buffer-next":      {scopeGlobal, genBufferCycle(bl, 1)},
+		"buffer-prev":      {scopeGlobal, genBufferCycle(bl, -1)},
+		"toggle-names":     {scopeGlobal, genToggle(showNames)},
+		"format-bold":      {scopeEntry, genInsertCode(ircBold)},
+		"format-italic":    {scopeEntry, genInsertCode(ircItalic)},
+		"format-underline": {scopeEntry, genInsertCode(ircUnderline)},
+		"format-reset":     {scopeEntry, genInsertCode(ircReset)},
+		"format-colour":    {scopeEntry, genColourPicker(showPalette)},
+		"history-prev":     {scopeEntry, genEntryReplace(bl, h.Prev)},
+		"history-next":     {scopeEntry, genEntryReplace(bl, h.Next)},
+		"history-search":   {scopeEntry, genEntryReplace(bl, h.Search)},
+		"complete":         {scopeEntry, genEntryReplace(bl, cp.Complete)},
+	}
+
+	for i := 1; i <= 10; i++ {
+		actions[fmt.Sprintf("buffer-%d", i)] = keyAction{scopeGlobal, genBufferSelect(bl, i-1)}
+	}
+
+	return actions
+}
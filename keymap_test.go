@@ -0,0 +1,173 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKey(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  keybinding
+		err  bool
+	}{
+		{
+			name: "character",
+			in:   "j",
+			out:  keybinding{key: 'j', mod: gocui.ModNone},
+		},
+		{
+			name: "upper case character",
+			in:   "J",
+			out:  keybinding{key: 'J', mod: gocui.ModNone},
+		},
+		{
+			name: "alt character",
+			in:   "alt+1",
+			out:  keybinding{key: '1', mod: gocui.ModAlt},
+		},
+		{
+			name: "named key",
+			in:   "Enter",
+			out:  keybinding{key: gocui.KeyEnter, mod: gocui.ModNone},
+		},
+		{
+			name: "ctrl key",
+			in:   "ctrl+u",
+			out:  keybinding{key: gocui.KeyCtrlU, mod: gocui.ModNone},
+		},
+		{
+			name: "function key",
+			in:   "f12",
+			out:  keybinding{key: gocui.KeyF12, mod: gocui.ModNone},
+		},
+		{
+			name: "alt named key",
+			in:   "alt+right",
+			out:  keybinding{key: gocui.KeyArrowRight, mod: gocui.ModAlt},
+		},
+		{
+			name: "unknown key",
+			in:   "hyper+x",
+			err:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseKey(tc.in)
+			if tc.err {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got, err := parseKeys("J, pgdn")
+	assert.Nil(t, err)
+	assert.Equal(t, []keybinding{{key: 'J'}, {key: gocui.KeyPgdn}}, got)
+
+	got, err = parseKeys("")
+	assert.Nil(t, err)
+	assert.Equal(t, []keybinding{}, got)
+}
+
+func testActions() map[string]keyAction {
+	return createActions(testBufferList(), &inputHistory{}, &completer{}, &toggle{}, &toggle{}, quit, func(buffer string) {})
+}
+
+func TestCreateKeymapPresets(t *testing.T) {
+	for preset := range keymapPresets {
+		t.Run(preset, func(t *testing.T) {
+			km, err := createKeymap(preset, nil, testActions())
+			assert.Nil(t, err)
+			assert.Equal(t, []keybinding{{key: '1', mod: gocui.ModAlt}}, km["buffer-1"])
+			assert.Equal(t, []keybinding{{key: '0', mod: gocui.ModAlt}}, km["buffer-10"])
+		})
+	}
+}
+
+func TestCreateKeymap(t *testing.T) {
+	cases := []struct {
+		name      string
+		preset    string
+		overrides map[string]string
+		err       bool
+	}{
+		{
+			name:      "override",
+			preset:    "vi",
+			overrides: map[string]string{"quit": "ctrl+q"},
+		},
+		{
+			name:      "unbind",
+			preset:    "vi",
+			overrides: map[string]string{"toggle-names": ""},
+		},
+		{
+			name:   "unknown preset",
+			preset: "nano",
+			err:    true,
+		},
+		{
+			name:      "unknown action",
+			preset:    "vi",
+			overrides: map[string]string{"dance": "d"},
+			err:       true,
+		},
+		{
+			name:      "invalid key",
+			preset:    "vi",
+			overrides: map[string]string{"quit": "ctrl+shift+q"},
+			err:       true,
+		},
+		{
+			name:      "character in entry view",
+			preset:    "vi",
+			overrides: map[string]string{"send": "s"},
+			err:       true,
+		},
+		{
+			name:      "conflicting keys",
+			preset:    "vi",
+			overrides: map[string]string{"quit": "ctrl+n"},
+			err:       true,
+		},
+		{
+			name:      "global alt character conflicts with entry",
+			preset:    "vi",
+			overrides: map[string]string{"scroll-down": "alt+b", "format-bold": "alt+b"},
+			err:       true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			km, err := createKeymap(tc.preset, tc.overrides, testActions())
+			if tc.err {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+
+			for action, keys := range tc.overrides {
+				kbs, _ := parseKeys(keys)
+				assert.Equal(t, kbs, km[action])
+			}
+		})
+	}
+}
+
+func TestCreateKeymapWithoutHandlers(t *testing.T) {
+	_, err := createKeymap("vi", nil, createActions(nil, nil, nil, nil, nil, nil, nil))
+	assert.Nil(t, err)
+}
@@ -3,54 +3,71 @@ package main
 import (
 	"fmt"
 	"io"
+	"sync"
 	"time"
 
 	"github.com/awesome-gocui/gocui"
 	"github.com/logrusorgru/aurora"
 )
 
-func genChatViewLoggerFunc(g *gocui.Gui) func(s string) {
-	return func(s string) {
+// genChatViewLoggerFunc writes lines to a buffer's chat view, recording them
+// in the scrollback at their message time from the gui goroutine so they
+// can't race with a redraw
+func genChatViewLoggerFunc(g *gocui.Gui, sb *scrollback, buffer string) func(s string, t time.Time) {
+	return func(s string, t time.Time) {
 		g.Update(func(g *gocui.Gui) error {
-			v, err := g.View("chat")
+			v, err := getChatView(g, buffer)
 			if err != nil {
 				return err
 			}
 
+			sb.Append(buffer, s, t)
 			fmt.Fprintln(v, s)
 			return nil
 		})
 	}
 }
 
-func genWriterLoggerFunc(w io.Writer) func(s string) {
-	return func(s string) {
+func genWriterLoggerFunc(w io.Writer) func(s string, t time.Time) {
+	return func(s string, t time.Time) {
 		fmt.Fprintln(w, s)
 	}
 }
 
 type logger struct {
-	loggerFunc func(s string)
+	sync.Mutex
+	loggerFunc func(s string, t time.Time)
+	clock      *clock
+	lastDate   string
 }
 
-func (c *logger) Log(s string, tt ...string) {
-	var t string
+// Log writes a line stamped with the given time, or now, announcing the day
+// changing since the previous line
+func (c *logger) Log(s string, tt ...time.Time) {
+	c.Lock()
+	defer c.Unlock()
 
-	if len(tt) == 0 {
-		now := time.Now()
-		t = now.Format("15:04")
-	} else {
+	t := time.Now()
+	if len(tt) > 0 {
 		t = tt[0]
 	}
 
-	ft := aurora.Bold(t).String()
-	out := fmt.Sprintf("%s %s", ft, s)
+	date := c.clock.Date(t)
+	if c.lastDate != "" && date != c.lastDate {
+		c.loggerFunc(formatDayChange(c.clock.In(t)), t)
+	}
+	c.lastDate = date
+
+	c.loggerFunc(formatLogLine(s, c.clock.Format(t)), t)
+}
 
-	c.loggerFunc(out)
+func formatLogLine(s, t string) string {
+	return fmt.Sprintf("%s %s", aurora.Bold(t).String(), s)
 }
 
-func createLogger(f func(s string)) *logger {
+func createLogger(f func(s string, t time.Time), ck *clock) *logger {
 	return &logger{
 		loggerFunc: f,
+		clock:      ck,
 	}
 }
@@ -1 +1,35 @@
 package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerLog(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := createLogger(genWriterLoggerFunc(b), testClock())
+
+	first := time.Date(2022, 10, 16, 23, 59, 0, 0, time.UTC)
+
+	l.Log("a", first)
+	l.Log("b", first.Add(30*time.Second))
+	l.Log("c", first.Add(time.Minute))
+
+	assert.Equal(t, "23:59 a\n23:59 b\n--- Day changed to Mon 17 Oct ---\n00:00 c\n", stripFormatting(b.String()))
+}
+
+func TestLoggerLogPassesTime(t *testing.T) {
+	times := []time.Time{}
+	l := createLogger(func(s string, t time.Time) {
+		times = append(times, t)
+	}, testClock())
+
+	at := time.Date(2022, 10, 16, 23, 59, 0, 0, time.UTC)
+	l.Log("a", at)
+	l.Log("b", at.Add(time.Minute))
+
+	assert.Equal(t, []time.Time{at, at.Add(time.Minute), at.Add(time.Minute)}, times)
+}
@@ -18,39 +18,188 @@ const (
 )
 
 type Options struct {
-	Broker      string   `short:"b" long:"broker" env:"NAKO_BROKER" default:"localhost:1883" description:"mqtt broker"`
-	TopicRoot   string   `short:"t" long:"topic-root" env:"NAKO_TOPIC_ROOT" default:"/gowon" description:"mqtt topic root"`
-	Channels    []string `short:"c" long:"channels" env:"NAKO_CHANNELS" env-delim:"," description:"Channels to watch"`
-	Highlights  []string `short:"H" long:"highlights" env:"NAKO_HIGHLIGHTS" env-delim:"," description:"Words to highlight"`
-	ShowJoins   bool     `short:"j" long:"show-joins" env:"NAKO_SHOW_JOINS" description:"Show join and part messages"`
-	ColourSeed  int      `short:"s" long:"color-seed" env:"NAKO_COLOUR_SEED" default:"0" description:"Colour seed"`
-	ColourBound int      `short:"B" long:"color-bound" env:"NAKO_COLOUR_BOUND" default:"7" description:"Color bound (0-n)"`
+	Config         string   `long:"config" env:"NAKO_CONFIG" description:"Config file (default: $XDG_CONFIG_HOME/nako/config.yaml)" yaml:"-"`
+	Broker         string   `short:"b" long:"broker" env:"NAKO_BROKER" default:"localhost:1883" description:"mqtt broker" yaml:"broker"`
+	TopicRoot      string   `short:"t" long:"topic-root" env:"NAKO_TOPIC_ROOT" default:"/gowon" description:"mqtt topic root" yaml:"topic-root"`
+	Channels       []string `short:"c" long:"channels" env:"NAKO_CHANNELS" env-delim:"," description:"Channels to watch" yaml:"channels"`
+	Nick           string   `short:"n" long:"nick" env:"NAKO_NICK" description:"Own nick to highlight (default: learnt from the server)" yaml:"nick"`
+	Highlights     []string `short:"H" long:"highlights" env:"NAKO_HIGHLIGHTS" env-delim:"," description:"Words to highlight" yaml:"highlights"`
+	ShowJoins      bool     `short:"j" long:"show-joins" env:"NAKO_SHOW_JOINS" description:"Show join and part messages" yaml:"show-joins"`
+	ShowQuits      bool     `long:"show-quits" env:"NAKO_SHOW_QUITS" description:"Show quit messages" yaml:"show-quits"`
+	ShowKicks      bool     `long:"show-kicks" env:"NAKO_SHOW_KICKS" description:"Show kick messages" yaml:"show-kicks"`
+	ShowNicks      bool     `long:"show-nicks" env:"NAKO_SHOW_NICKS" description:"Show nick change messages" yaml:"show-nicks"`
+	ShowModes      bool     `long:"show-modes" env:"NAKO_SHOW_MODES" description:"Show mode change messages" yaml:"show-modes"`
+	Keymap         string   `short:"k" long:"keymap" env:"NAKO_KEYMAP" default:"vi" choice:"vi" choice:"emacs" description:"Keybinding preset" yaml:"keymap"`
+	Markup         bool     `short:"m" long:"markup" env:"NAKO_MARKUP" description:"Convert *bold* and _italic_ markup in sent messages" yaml:"markup"`
+	HistoryFile    string   `long:"history-file" env:"NAKO_HISTORY_FILE" description:"Input history file (default: $XDG_STATE_HOME/nako/history.json)" yaml:"history-file"`
+	HistorySize    int      `long:"history-size" env:"NAKO_HISTORY_SIZE" default:"100" description:"Lines of input history kept per channel" yaml:"history-size"`
+	Log            bool     `short:"l" long:"log" env:"NAKO_LOG" description:"Log chat to disk" yaml:"log"`
+	LogDir         string   `long:"log-dir" env:"NAKO_LOG_DIR" description:"Chat log directory (default: $XDG_STATE_HOME/nako/logs)" yaml:"log-dir"`
+	LogJSON        bool     `long:"log-json" env:"NAKO_LOG_JSON" description:"Also write chat logs as json lines" yaml:"log-json"`
+	Restore        int      `short:"r" long:"restore" env:"NAKO_RESTORE" default:"50" description:"Lines of chat log to restore per channel on startup" yaml:"restore"`
+	Notify         []string `long:"notify" env:"NAKO_NOTIFY" env-delim:"," choice:"bell" choice:"osc9" choice:"osc777" choice:"command" description:"Notify on highlights and private messages" yaml:"notify"`
+	NotifyCommand  string   `long:"notify-command" env:"NAKO_NOTIFY_COMMAND" description:"Command run for command notifications, given NAKO_KIND, NAKO_BUFFER, NAKO_NICK, NAKO_MESSAGE and NAKO_TITLE" yaml:"notify-command"`
+	NotifyInterval int      `long:"notify-interval" env:"NAKO_NOTIFY_INTERVAL" default:"10" description:"Minimum seconds between notifications" yaml:"notify-interval"`
+	TimeFormat     string   `long:"time-format" env:"NAKO_TIME_FORMAT" default:"15:04" description:"Timestamp format, as a go time layout" yaml:"time-format"`
+	TimeZone       string   `long:"time-zone" env:"NAKO_TIME_ZONE" description:"Timestamp time zone (default: local)" yaml:"time-zone"`
+	ColourSeed     int      `short:"s" long:"color-seed" env:"NAKO_COLOUR_SEED" default:"0" description:"Colour seed" yaml:"color-seed"`
+	ColourBound    int      `short:"B" long:"color-bound" env:"NAKO_COLOUR_BOUND" default:"7" description:"Color bound (0-n)" yaml:"color-bound"`
 }
 
 func main() {
 	// Parse options
 
 	opts := Options{}
-	_, err := flags.Parse(&opts)
+	parser := flags.NewParser(&opts, flags.Default)
+	_, err := parser.Parse()
 	if err != nil {
 		os.Exit(1)
 	}
 
+	cfg, err := loadConfig(opts, groupOptions(parser.Groups()))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	opts = cfg.Options
+
+	theme, err := createTheme(cfg.Theme)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	channelSettings := createChannelSettings(cfg)
+
+	highlighter, err := createHighlighter(cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ignoreList, err := createIgnoreList(cfg.Ignores, func(ics []ignoreConfig) error {
+		return saveConfigKey(configPath(opts), "ignores", ics)
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	clock, err := createClock(opts.TimeZone, opts.TimeFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	historyFile := opts.HistoryFile
+	if historyFile == "" {
+		historyFile = defaultStatePath("history.json")
+	}
+
+	history, err := createInputHistory(historyFile, opts.HistorySize)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := validateNotifiers(opts.Notify, opts.NotifyCommand); err != nil {
+		log.Fatalln(err)
+	}
+
+	// actions are only needed for their scopes here, their handlers are set
+	// up once the gui and mqtt client exist
+	km, err := createKeymap(opts.Keymap, cfg.Keybindings, createActions(nil, nil, nil, nil, nil, nil, nil))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	logDir := opts.LogDir
+	if logDir == "" {
+		logDir = defaultStatePath("logs")
+	}
+
+	if opts.Log && logDir == "" {
+		log.Fatalln("no directory for chat logs could be found, set log-dir")
+	}
+
 	// Create gui
 
-	g, err := gocui.NewGui(gocui.OutputNormal, true)
+	g, err := gocui.NewGui(gocui.OutputTrue, true)
 	if err != nil {
 		log.Panicln(err)
 	}
 	defer g.Close()
 
-	g.Highlight = true
-	g.SetManagerFunc(genLayout(opts.Channels))
+	// Setup buffers and application logger
 
-	// Setup application logger
+	var appLogger *logger
 
-	loggerFunc := genChatViewLoggerFunc(g)
-	appLogger := createLogger(loggerFunc)
+	chatLog := createChatLog(logDir, opts.LogJSON, func(err error) {
+		appLogger.Log(fmt.Sprintf("chat logging failed: %s", err))
+	})
+	defer chatLog.Close()
+
+	// redraw reruns the layout so the topic and nick list views pick up
+	// changes made from the mqtt handlers
+	redraw := func() {
+		g.Update(func(g *gocui.Gui) error {
+			return nil
+		})
+	}
+
+	var chatScrollback *scrollback
+	chatScrollback = createScrollback(func(buffer string) {
+		g.Update(func(g *gocui.Gui) error {
+			return redrawChatView(g, chatScrollback, buffer)
+		})
+	})
+
+	bufferLoggerFunc := func(buffer string) func(s string, t time.Time) {
+		viewLoggerFunc := genChatViewLoggerFunc(g, chatScrollback, buffer)
+
+		if !opts.Log {
+			return viewLoggerFunc
+		}
+
+		diskLoggerFunc := chatLog.LoggerFunc(buffer)
+
+		return func(s string, t time.Time) {
+			viewLoggerFunc(s, t)
+			diskLoggerFunc(s, t)
+		}
+	}
+	channels := createChannelSet(opts.Channels...)
+	buffers := createBufferList(bufferLoggerFunc, chatLog.CloseBuffer, clock, opts.Channels...)
+	appLogger = buffers.Logger(statusBuffer)
+
+	for _, channel := range opts.Channels {
+		if logDir == "" {
+			break
+		}
+
+		if err := restoreChatLog(logDir, channel, opts.Restore, chatScrollback); err != nil {
+			appLogger.Log(err.Error())
+		}
+	}
+
+	activityTracker := createActivityTracker()
+	memberList := createMemberList()
+	topicStore := createTopicStore()
+	recentSpeakers := createRecentSpeakers()
+	batchTracker := createBatchTracker()
+	whoisTracker := createWhoisTracker()
+	hostCache := createHostCache()
+	completer := createCompleter(channels, memberList, recentSpeakers, highlighter)
+	colourAllocator := createColourAllocator(opts.ColourSeed)
+	focused := func(buffer string) bool {
+		return buffers.Active() == buffer
+	}
+
+	notifications, err := createNotificationManager(opts.Notify, opts.NotifyCommand, time.Duration(opts.NotifyInterval)*time.Second, guiWriter{g: g, w: os.Stdout, l: appLogger}, focused, appLogger)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	showNames := &toggle{}
+	showPalette := &toggle{}
+
+	g.Highlight = true
+	g.SetManagerFunc(genLayout(buffers, activityTracker, memberList, topicStore, showNames, showPalette, opts.Markup, theme, colourAllocator, clock))
 
 	// Setup mqtt client
 
@@ -68,59 +217,28 @@ func main() {
 	mqttOpts.OnConnectionLost = genOnConnectionLostHandler(appLogger)
 	mqttOpts.OnReconnecting = genOnRecconnectingHandler(appLogger)
 
-	colourAllocator := createColourAllocator(opts.ColourSeed)
-	privMsgHandler := genPrivMsgHandler(opts.Channels, opts.Highlights, colourAllocator, appLogger)
-	rawMsgHandler := genRawMsgHandler(opts.Channels, colourAllocator, appLogger)
-	mqttOpts.OnConnect = createOnConnectHandler(opts.TopicRoot, opts.Channels, privMsgHandler, rawMsgHandler, appLogger)
-
-	// Connect to mqtt broker
-
-	appLogger.Log("connecting to broker")
+	privMsgHandler := genPrivMsgHandler(channels, highlighter, ignoreList, colourAllocator, buffers, activityTracker, recentSpeakers, batchTracker, notifications, clock, appLogger)
+	rawMsgHandler := genRawMsgHandler(channels, channelSettings, colourAllocator, buffers, memberList, hostCache, topicStore, highlighter, ignoreList, batchTracker, whoisTracker, chatScrollback, clock, redraw, appLogger)
+	mqttOpts.OnConnect = createOnConnectHandler(opts.TopicRoot, channels, privMsgHandler, rawMsgHandler, appLogger)
 
 	c := mqtt.NewClient(mqttOpts)
-	if token := c.Connect(); token.Wait() && token.Error() != nil {
-		panic(token.Error())
-	}
 
 	// Setup gui keybindings
 
-	if err := g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, quit); err != nil {
-		log.Panicln(err)
-	}
-
-	if len(opts.Channels) == 1 {
-		if err := g.SetKeybinding("chat", gocui.KeyTab, gocui.ModNone, entrySwitch); err != nil {
-			log.Panicln(err)
-		}
+	sendMessage := genSendMessage(c, clientId, opts.TopicRoot, opts.Markup, channels, buffers, memberList, chatScrollback, history, ignoreList, hostCache, whoisTracker, &rawConfirmer{}, appLogger)
+	requestHistory := genRequestHistoryBefore(c, opts.TopicRoot, batchTracker)
+	actions := createActions(buffers, history, completer, showNames, showPalette, sendMessage, requestHistory)
 
-		if err := g.SetKeybinding("entry", gocui.KeyTab, gocui.ModNone, chatSwitch); err != nil {
-			log.Panicln(err)
-		}
-
-		if err := g.SetKeybinding("entry", gocui.KeyCtrlU, gocui.ModNone, entryClear); err != nil {
-			log.Panicln(err)
-		}
-
-		sendMessage := genSendMessage(c, clientId, opts.TopicRoot, opts.Channels[0], appLogger)
-		if err := g.SetKeybinding("entry", gocui.KeyEnter, gocui.ModNone, sendMessage); err != nil {
-			log.Panicln(err)
-		}
-	}
-
-	if err := g.SetKeybinding("chat", 'j', gocui.ModNone, genScrollX(1)); err != nil {
+	if err := km.Apply(g, actions); err != nil {
 		log.Panicln(err)
 	}
 
-	if err := g.SetKeybinding("chat", 'k', gocui.ModNone, genScrollX(-1)); err != nil {
-		log.Panicln(err)
-	}
+	// Connect to mqtt broker
 
-	if err := g.SetKeybinding("chat", 'J', gocui.ModNone, genScrollX(10)); err != nil {
-		log.Panicln(err)
-	}
+	appLogger.Log("connecting to broker")
 
-	if err := g.SetKeybinding("chat", 'K', gocui.ModNone, genScrollX(-10)); err != nil {
-		log.Panicln(err)
+	if token := c.Connect(); token.Wait() && token.Error() != nil {
+		panic(token.Error())
 	}
 
 	// Start gui
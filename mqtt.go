@@ -28,7 +28,7 @@ func genOnRecconnectingHandler(l *logger) func(c mqtt.Client, opts *mqtt.ClientO
 	}
 }
 
-func genPrivMsgHandler(channels, highlights []string, ca *colourAllocator, l *logger) func(client mqtt.Client, msg mqtt.Message) {
+func genPrivMsgHandler(chans *channelSet, hl *highlighter, il *ignoreList, ca *colourAllocator, bl *bufferList, at *activityTracker, rs *recentSpeakers, bt *batchTracker, nm *notificationManager, ck *clock, l *logger) func(client mqtt.Client, msg mqtt.Message) {
 	return func(client mqtt.Client, msg mqtt.Message) {
 		m, err := gowon.CreateMessageStruct(msg.Payload())
 
@@ -37,39 +37,65 @@ func genPrivMsgHandler(channels, highlights []string, ca *colourAllocator, l *lo
 			return
 		}
 
-		if len(channels) > 0 && !containsString(channels, m.Dest) {
+		if isChannel(m.Dest) && !chans.Watching(m.Dest) {
 			return
 		}
 
+		buffer := messageBuffer(m.Nick, m.Dest, hl.Nick())
+		private := !isChannel(buffer)
+
 		id := ca.Allocate(m.Nick)
-		out := aurora.Index(id, fmt.Sprintf("%s: %s", m.Nick, m.Msg))
+		text, format := m.Msg, "%s: %s"
 
-		for _, h := range highlights {
-			if strings.Contains(m.Msg, h) {
-				out = out.Black().BgIndex(id)
-			}
+		if action, ok := parseAction(m.Msg); ok {
+			text, format = action, "* %s %s"
+		}
+
+		if il.Ignored(buffer, m.Nick, m.User, m.Host, text) {
+			return
 		}
 
-		output := ircToAnsiColours(out.String())
+		highlightedText, highlighted := hl.Highlight(buffer, text)
 
-		serverTime := m.Tags["time"]
+		out := aurora.Index(id, fmt.Sprintf(format, m.Nick, highlightedText))
+		output := closeHighlights(out.String())
 
-		if serverTime == "" {
-			l.Log(output)
+		t, err := parseServerTime(m.Tags["time"])
+		if err != nil {
+			t = time.Now()
+		}
+
+		line := historyLine{msgid: m.Tags["msgid"], at: t, text: formatLogLine(output, ck.Format(t))}
+		if bt.Add(m.Tags["batch"], line) {
 			return
 		}
 
-		t, err := time.Parse("2006-01-02T15:04:05.000Z", serverTime)
-		if err != nil {
-			l.Log(output)
+		if !bt.See(buffer, line) {
+			return
+		}
+
+		own := m.Nick == ownMessageNick
+
+		at.Message(buffer, highlighted || (private && !own))
+
+		if !own {
+			rs.Spoke(buffer, m.Nick)
+		}
+
+		bl.Logger(buffer).Log(output, t)
+
+		if own || !(highlighted || private) {
 			return
 		}
 
-		l.Log(output, t.Format("15:04"))
+		n := notification{buffer: buffer, nick: m.Nick, text: text, private: private}
+		if err := nm.Notify(n); err != nil {
+			l.Log(err.Error())
+		}
 	}
 }
 
-func genRawMsgHandler(channels []string, ca *colourAllocator, l *logger) func(client mqtt.Client, msg mqtt.Message) {
+func genRawMsgHandler(chans *channelSet, cs *channelSettings, ca *colourAllocator, bl *bufferList, ml *memberList, hc *hostCache, ts *topicStore, hl *highlighter, il *ignoreList, bt *batchTracker, wt *whoisTracker, sb *scrollback, ck *clock, redraw func(), l *logger) func(client mqtt.Client, msg mqtt.Message) {
 	return func(client mqtt.Client, msg mqtt.Message) {
 		m, err := gowon.CreateMessageStruct(msg.Payload())
 
@@ -79,38 +105,303 @@ func genRawMsgHandler(channels []string, ca *colourAllocator, l *logger) func(cl
 		}
 
 		id := ca.Allocate(m.Nick)
+		hc.Seen(m.Nick, m.Host)
 
-		if m.Code == "JOIN" {
-			if len(channels) > 0 && !containsString(channels, m.Arguments[0]) {
+		// events are shown if enabled for the channel and not from someone ignored
+		show := func(channel string) bool {
+			return cs.ShowEvent(channel, m.Code) && !il.Ignored(channel, m.Nick, m.User, m.Host, "")
+		}
+
+		if m.Code == "BATCH" && len(m.Arguments) > 0 {
+			ref, opening := parseBatch(m.Arguments[0])
+
+			if opening && len(m.Arguments) > 2 && chans.Watching(m.Arguments[2]) {
+				bt.Open(ref, m.Arguments[1], m.Arguments[2])
+			}
+
+			if opening {
 				return
 			}
 
-			out := aurora.Index(id, fmt.Sprintf("-> %s joined %s", m.Nick, m.Arguments[0])).String()
-			l.Log(out)
+			if target, lines, ok := bt.Close(ref); ok {
+				sb.Insert(target, formatHistory(lines, ck))
+			}
+
+			return
+		}
+
+		if m.Code == "001" && len(m.Arguments) > 0 {
+			hl.SetNick(m.Arguments[0])
+			return
+		}
+
+		if m.Code == "311" && len(m.Arguments) > 3 {
+			hc.Seen(m.Arguments[1], m.Arguments[3])
+		}
+
+		if len(m.Arguments) > 1 && wt.Add(m.Code, m.Arguments[1:]) {
+			return
+		}
+
+		if (m.Code == "318" || m.Code == "369") && len(m.Arguments) > 1 {
+			for _, w := range wt.End(m.Arguments[1]) {
+				for _, line := range formatWhois(w, ck) {
+					l.Log(line)
+				}
+			}
+
 			return
 		}
 
-		if m.Code == "332" {
-			if len(channels) > 0 && !containsString(channels, m.Arguments[1]) {
+		if isErrorNumeric(m.Code) {
+			out := ircToAnsiColours(aurora.Red(formatErrorNumeric(m.Code, m.Arguments)).String())
+
+			if channel, ok := errorChannel(m.Arguments); ok && chans.Watching(channel) {
+				bl.Logger(channel).Log(out)
 				return
 			}
 
-			out := fmt.Sprintf("topic for %s is: \"%s\"", m.Arguments[1], m.Arguments[2])
+			// errors about a nick, like 401 after /msg, go to its query
+			if len(m.Arguments) > 2 {
+				if ql, ok := bl.Get(m.Arguments[1]); ok {
+					ql.Log(out)
+					return
+				}
+			}
+
 			l.Log(out)
+			return
+		}
+
+		if m.Code == "NOTICE" && len(m.Arguments) > 1 {
+			target, text := m.Arguments[0], formatNoticeText(m.Arguments[1])
+
+			t, err := parseServerTime(m.Tags["time"])
+			if err != nil {
+				t = time.Now()
+			}
+
+			if isServerSource(m.Nick, m.User) {
+				l.Log(ircToAnsiColours(aurora.Faint(formatNotice(m.Nick, text)).String()), t)
+				return
+			}
+
+			if il.Ignored(target, m.Nick, m.User, m.Host, text) {
+				return
+			}
+
+			if isChannel(target) && !chans.Watching(target) {
+				return
+			}
+
+			out := ircToAnsiColours(aurora.Index(id, formatNotice(m.Nick, text)).String())
+
+			// notices replayed by chathistory join the rest of the batch
+			line := historyLine{msgid: m.Tags["msgid"], at: t, text: formatLogLine(out, ck.Format(t))}
+			if bt.Add(m.Tags["batch"], line) {
+				return
+			}
+
+			if isChannel(target) {
+				if bt.See(target, line) {
+					bl.Logger(target).Log(out, t)
+				}
+
+				return
+			}
+
+			l.Log(out, t)
+			return
+		}
+
+		if m.Code == "324" && len(m.Arguments) > 2 {
+			if chans.Watching(m.Arguments[1]) {
+				bl.Logger(m.Arguments[1]).Log(fmt.Sprintf("%s modes: %s", m.Arguments[1], strings.Join(m.Arguments[2:], " ")))
+			}
+
+			return
+		}
+
+		if m.Code == "JOIN" {
+			if !chans.Watching(m.Arguments[0]) {
+				return
+			}
+
+			ml.Join(m.Arguments[0], m.Nick)
+			redraw()
+
+			if !show(m.Arguments[0]) {
+				return
+			}
+
+			out := aurora.Index(id, formatJoin(m.Nick, m.Arguments[0])).String()
+			bl.Logger(m.Arguments[0]).Log(out)
+			return
+		}
+
+		if m.Code == "331" && len(m.Arguments) > 1 {
+			if !chans.Watching(m.Arguments[1]) {
+				return
+			}
+
+			ts.Set(m.Arguments[1], topic{})
+			redraw()
+		}
+
+		if m.Code == "332" && len(m.Arguments) > 2 {
+			if !chans.Watching(m.Arguments[1]) {
+				return
+			}
+
+			ts.SetText(m.Arguments[1], m.Arguments[2])
+			redraw()
+		}
+
+		if m.Code == "333" && len(m.Arguments) > 3 {
+			if !chans.Watching(m.Arguments[1]) {
+				return
+			}
+
+			setAt, err := parseUnixTime(m.Arguments[3])
+			if err != nil {
+				l.Log(err.Error())
+				return
+			}
+
+			ts.SetWho(m.Arguments[1], m.Arguments[2], setAt)
+			redraw()
+		}
+
+		if m.Code == "TOPIC" && len(m.Arguments) > 1 {
+			if !chans.Watching(m.Arguments[0]) {
+				return
+			}
+
+			t, err := parseServerTime(m.Tags["time"])
+			if err != nil {
+				t = time.Now()
+			}
+
+			ts.Set(m.Arguments[0], topic{
+				text:  m.Arguments[1],
+				setBy: m.Nick,
+				setAt: t,
+			})
+			redraw()
+
+			out := fmt.Sprintf("%s changed the topic to: \"%s\"", aurora.Index(id, m.Nick), ircToAnsiColours(m.Arguments[1]))
+			bl.Logger(m.Arguments[0]).Log(out, t)
 		}
 
 		if m.Code == "353" {
-			if len(channels) > 0 && !containsString(channels, m.Arguments[2]) {
+			if !chans.Watching(m.Arguments[2]) {
 				return
 			}
 
+			ml.Names(m.Arguments[2], m.Arguments[3])
+
 			out := fmt.Sprintf("In %s are: %s", m.Arguments[2], colourNamesList(m.Arguments[3], ca))
-			l.Log(out)
+			bl.Logger(m.Arguments[2]).Log(out)
+		}
+
+		if m.Code == "366" && len(m.Arguments) > 1 {
+			ml.EndNames(m.Arguments[1])
+			redraw()
+		}
+
+		if m.Code == "PART" && len(m.Arguments) > 0 {
+			if !chans.Watching(m.Arguments[0]) {
+				return
+			}
+
+			ml.Part(m.Arguments[0], m.Nick)
+			redraw()
+
+			if !show(m.Arguments[0]) {
+				return
+			}
+
+			// closed buffers aren't reopened for the echo of our own part
+			if cl, ok := bl.Get(m.Arguments[0]); ok {
+				out := formatPart(m.Nick, m.Arguments[0], argOrEmpty(m.Arguments, 1))
+				cl.Log(ircToAnsiColours(aurora.Index(id, out).String()))
+			}
+		}
+
+		if m.Code == "KICK" && len(m.Arguments) > 1 {
+			if !chans.Watching(m.Arguments[0]) {
+				return
+			}
+
+			ml.Part(m.Arguments[0], m.Arguments[1])
+			redraw()
+
+			if !show(m.Arguments[0]) {
+				return
+			}
+
+			if cl, ok := bl.Get(m.Arguments[0]); ok {
+				out := formatKick(m.Nick, m.Arguments[1], m.Arguments[0], argOrEmpty(m.Arguments, 2))
+				cl.Log(ircToAnsiColours(aurora.Index(id, out).String()))
+			}
+		}
+
+		if m.Code == "QUIT" {
+			quitChannels := ml.Quit(m.Nick)
+			redraw()
+			out := formatQuit(m.Nick, argOrEmpty(m.Arguments, 0))
+
+			for _, c := range quitChannels {
+				if chans.Watching(c) && show(c) {
+					bl.Logger(c).Log(ircToAnsiColours(aurora.Index(id, out).String()))
+				}
+			}
+		}
+
+		if m.Code == "NICK" && len(m.Arguments) > 0 {
+			if strings.EqualFold(m.Nick, hl.Nick()) {
+				hl.SetNick(m.Arguments[0])
+			}
+
+			hc.Nick(m.Nick, m.Arguments[0])
+			nickChannels := ml.Nick(m.Nick, m.Arguments[0])
+			redraw()
+			out := aurora.Index(ca.Allocate(m.Arguments[0]), formatNick(m.Nick, m.Arguments[0])).String()
+
+			for _, c := range nickChannels {
+				if chans.Watching(c) && show(c) {
+					bl.Logger(c).Log(out)
+				}
+			}
+		}
+
+		if m.Code == "MODE" && len(m.Arguments) > 1 {
+			target := m.Arguments[0]
+
+			if isChannel(target) && !chans.Watching(target) {
+				return
+			}
+
+			ml.Mode(target, m.Arguments[1], m.Arguments[2:])
+			redraw()
+
+			if !show(target) {
+				return
+			}
+
+			out := aurora.Index(id, formatMode(m.Nick, target, m.Arguments[1:])).String()
+
+			if !isChannel(target) {
+				l.Log(out)
+				return
+			}
+
+			bl.Logger(target).Log(out)
 		}
 	}
 }
 
-func createOnConnectHandler(topicRoot string, channels []string, pmh, rmh mqtt.MessageHandler, l *logger) func(mqtt.Client) {
+func createOnConnectHandler(topicRoot string, chans *channelSet, pmh, rmh mqtt.MessageHandler, l *logger) func(mqtt.Client) {
 	inputTopic := topicRoot + "/input"
 	rawInputTopic := topicRoot + "/raw/input"
 	rawOutputTopic := topicRoot + "/raw/output"
@@ -124,7 +415,10 @@ func createOnConnectHandler(topicRoot string, channels []string, pmh, rmh mqtt.M
 		client.Subscribe(rawInputTopic, 0, rmh)
 		l.Log(fmt.Sprintf(fmt.Sprintf("Subscription to %s complete", rawInputTopic)))
 
-		client.Publish(rawOutputTopic, 0, false, fmt.Sprintf("JOIN %s", strings.Join(channels, ",")))
+		channels := chans.List()
+		if len(channels) > 0 {
+			client.Publish(rawOutputTopic, 0, false, formatRejoinCommand(channels, chans.Key))
+		}
 
 		for _, c := range channels {
 			client.Publish(rawOutputTopic, 0, false, fmt.Sprintf("TOPIC %s", c))
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+const memberPrefixes = "~&@%+"
+
+var memberModes = map[byte]byte{
+	'q': '~',
+	'a': '&',
+	'o': '@',
+	'h': '%',
+	'v': '+',
+}
+
+func splitMemberPrefix(name string) (prefix, nick string) {
+	nick = strings.TrimLeft(name, memberPrefixes)
+	return name[:len(name)-len(nick)], nick
+}
+
+func sortPrefixes(prefix string) string {
+	b := []byte(prefix)
+
+	sort.Slice(b, func(i, j int) bool {
+		return strings.IndexByte(memberPrefixes, b[i]) < strings.IndexByte(memberPrefixes, b[j])
+	})
+
+	return string(b)
+}
+
+func modeTakesArg(mode byte, adding bool) bool {
+	if _, p := memberModes[mode]; p {
+		return true
+	}
+
+	if strings.IndexByte("beIk", mode) >= 0 {
+		return true
+	}
+
+	return mode == 'l' && adding
+}
+
+type memberList struct {
+	sync.Mutex
+	channels map[string]map[string]string
+	pending  map[string]map[string]string
+}
+
+func (ml *memberList) Names(channel, names string) {
+	ml.Lock()
+	defer ml.Unlock()
+
+	members, p := ml.pending[channel]
+	if !p {
+		members = make(map[string]string)
+		ml.pending[channel] = members
+	}
+
+	for _, name := range strings.Fields(names) {
+		prefix, nick := splitMemberPrefix(name)
+		members[nick] = sortPrefixes(prefix)
+	}
+}
+
+func (ml *memberList) EndNames(channel string) {
+	ml.Lock()
+	defer ml.Unlock()
+
+	members, p := ml.pending[channel]
+	if !p {
+		members = make(map[string]string)
+	}
+
+	ml.channels[channel] = members
+	delete(ml.pending, channel)
+}
+
+func (ml *memberList) Join(channel, nick string) {
+	ml.Lock()
+	defer ml.Unlock()
+
+	members, p := ml.channels[channel]
+	if !p {
+		members = make(map[string]string)
+		ml.channels[channel] = members
+	}
+
+	members[nick] = ""
+}
+
+func (ml *memberList) Part(channel, nick string) {
+	ml.Lock()
+	defer ml.Unlock()
+
+	delete(ml.channels[channel], nick)
+}
+
+// Leave forgets a channel's members once we've left it
+func (ml *memberList) Leave(channel string) {
+	ml.Lock()
+	defer ml.Unlock()
+
+	delete(ml.channels, channel)
+	delete(ml.pending, channel)
+}
+
+func (ml *memberList) Quit(nick string) []string {
+	ml.Lock()
+	defer ml.Unlock()
+
+	channels := []string{}
+
+	for channel, members := range ml.channels {
+		if _, p := members[nick]; p {
+			delete(members, nick)
+			channels = append(channels, channel)
+		}
+	}
+
+	sort.Strings(channels)
+
+	return channels
+}
+
+func (ml *memberList) Nick(oldNick, newNick string) []string {
+	ml.Lock()
+	defer ml.Unlock()
+
+	channels := []string{}
+
+	for channel, members := range ml.channels {
+		if prefix, p := members[oldNick]; p {
+			delete(members, oldNick)
+			members[newNick] = prefix
+			channels = append(channels, channel)
+		}
+	}
+
+	sort.Strings(channels)
+
+	return channels
+}
+
+func (ml *memberList) Mode(channel, modes string, args []string) {
+	ml.Lock()
+	defer ml.Unlock()
+
+	members := ml.channels[channel]
+	adding := true
+
+	for i := 0; i < len(modes); i++ {
+		mode := modes[i]
+
+		if mode == '+' || mode == '-' {
+			adding = mode == '+'
+			continue
+		}
+
+		if !modeTakesArg(mode, adding) {
+			continue
+		}
+
+		if len(args) == 0 {
+			return
+		}
+
+		arg := args[0]
+		args = args[1:]
+
+		prefix, isMemberMode := memberModes[mode]
+		if !isMemberMode || members == nil {
+			continue
+		}
+
+		current, p := members[arg]
+		if !p {
+			continue
+		}
+
+		current = strings.ReplaceAll(current, string(prefix), "")
+		if adding {
+			current = sortPrefixes(current + string(prefix))
+		}
+
+		members[arg] = current
+	}
+}
+
+func (ml *memberList) Members(channel string) []string {
+	ml.Lock()
+	defer ml.Unlock()
+
+	names := []string{}
+
+	for nick, prefix := range ml.channels[channel] {
+		if prefix != "" {
+			nick = prefix[:1] + nick
+		}
+		names = append(names, nick)
+	}
+
+	return sortNamesList(names)
+}
+
+func (ml *memberList) Nicks(channel string) []string {
+	ml.Lock()
+	defer ml.Unlock()
+
+	nicks := []string{}
+
+	for nick := range ml.channels[channel] {
+		nicks = append(nicks, nick)
+	}
+
+	sort.Strings(nicks)
+
+	return nicks
+}
+
+func createMemberList() *memberList {
+	return &memberList{
+		channels: make(map[string]map[string]string),
+		pending:  make(map[string]map[string]string),
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMemberPrefix(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     string
+		prefix string
+		nick   string
+	}{
+		{
+			name:   "no prefix",
+			in:     "nako",
+			prefix: "",
+			nick:   "nako",
+		},
+		{
+			name:   "one prefix",
+			in:     "@nako",
+			prefix: "@",
+			nick:   "nako",
+		},
+		{
+			name:   "multiple prefixes",
+			in:     "@+nako",
+			prefix: "@+",
+			nick:   "nako",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			prefix, nick := splitMemberPrefix(tc.in)
+			assert.Equal(t, tc.prefix, prefix)
+			assert.Equal(t, tc.nick, nick)
+		})
+	}
+}
+
+func TestMemberListNames(t *testing.T) {
+	ml := createMemberList()
+
+	ml.Names("#nako", "b @+a")
+	assert.Equal(t, []string{}, ml.Members("#nako"))
+
+	ml.Names("#nako", "~c")
+	ml.EndNames("#nako")
+	assert.Equal(t, []string{"~c", "@a", "b"}, ml.Members("#nako"))
+
+	ml.Names("#nako", "d")
+	ml.EndNames("#nako")
+	assert.Equal(t, []string{"d"}, ml.Members("#nako"))
+}
+
+func TestMemberListNicks(t *testing.T) {
+	ml := createMemberList()
+
+	ml.Names("#nako", "~c b @+a")
+	ml.EndNames("#nako")
+	assert.Equal(t, []string{"a", "b", "c"}, ml.Nicks("#nako"))
+	assert.Equal(t, []string{}, ml.Nicks("#gowon"))
+}
+
+func TestMemberListJoinPart(t *testing.T) {
+	ml := createMemberList()
+
+	ml.Join("#nako", "b")
+	ml.Join("#nako", "a")
+	assert.Equal(t, []string{"a", "b"}, ml.Members("#nako"))
+
+	ml.Part("#nako", "a")
+	assert.Equal(t, []string{"b"}, ml.Members("#nako"))
+
+	ml.Part("#gowon", "a")
+	assert.Equal(t, []string{}, ml.Members("#gowon"))
+}
+
+func TestMemberListLeave(t *testing.T) {
+	ml := createMemberList()
+
+	ml.Names("#nako", "a b")
+	ml.EndNames("#nako")
+	ml.Names("#nako", "c")
+	ml.Leave("#nako")
+
+	assert.Equal(t, []string{}, ml.Members("#nako"))
+	assert.Equal(t, []string{}, ml.Quit("a"))
+
+	ml.EndNames("#nako")
+	assert.Equal(t, []string{}, ml.Members("#nako"))
+}
+
+func TestMemberListQuitNick(t *testing.T) {
+	ml := createMemberList()
+
+	ml.Names("#nako", "@a b")
+	ml.EndNames("#nako")
+	ml.Names("#gowon", "a")
+	ml.EndNames("#gowon")
+
+	assert.Equal(t, []string{"#gowon", "#nako"}, ml.Nick("a", "c"))
+	assert.Equal(t, []string{"@c", "b"}, ml.Members("#nako"))
+	assert.Equal(t, []string{"c"}, ml.Members("#gowon"))
+
+	assert.Equal(t, []string{"#gowon", "#nako"}, ml.Quit("c"))
+	assert.Equal(t, []string{"b"}, ml.Members("#nako"))
+	assert.Equal(t, []string{}, ml.Quit("c"))
+}
+
+func TestMemberListMode(t *testing.T) {
+	cases := []struct {
+		name  string
+		modes string
+		args  []string
+		out   []string
+	}{
+		{
+			name:  "op",
+			modes: "+o",
+			args:  []string{"b"},
+			out:   []string{"@a", "@b", "c"},
+		},
+		{
+			name:  "deop",
+			modes: "-o",
+			args:  []string{"a"},
+			out:   []string{"a", "b", "c"},
+		},
+		{
+			name:  "voice with other modes",
+			modes: "+lkv",
+			args:  []string{"10", "key", "c"},
+			out:   []string{"@a", "+c", "b"},
+		},
+		{
+			name:  "mixed add and remove",
+			modes: "+v-o",
+			args:  []string{"a", "a"},
+			out:   []string{"+a", "b", "c"},
+		},
+		{
+			name:  "unknown nick",
+			modes: "+o",
+			args:  []string{"d"},
+			out:   []string{"@a", "b", "c"},
+		},
+		{
+			name:  "missing args",
+			modes: "+oo",
+			args:  []string{"b"},
+			out:   []string{"@a", "@b", "c"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ml := createMemberList()
+			ml.Names("#nako", "@a b c")
+			ml.EndNames("#nako")
+
+			ml.Mode("#nako", tc.modes, tc.args)
+			assert.Equal(t, tc.out, ml.Members("#nako"))
+		})
+	}
+}
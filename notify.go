@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+)
+
+type notification struct {
+	buffer  string
+	nick    string
+	text    string
+	private bool
+}
+
+func (n notification) title() string {
+	if n.private {
+		return fmt.Sprintf("nako: message from %s", n.nick)
+	}
+
+	return fmt.Sprintf("nako: %s highlighted you in %s", n.nick, n.buffer)
+}
+
+type notifier interface {
+	Notify(n notification) error
+}
+
+type bellNotifier struct {
+	w io.Writer
+}
+
+func (bn bellNotifier) Notify(n notification) error {
+	_, err := io.WriteString(bn.w, "\a")
+
+	return err
+}
+
+// guiWriter writes from the gui's main loop, so terminal escapes never land in
+// the middle of a screen update
+type guiWriter struct {
+	g *gocui.Gui
+	w io.Writer
+	l *logger
+}
+
+func (gw guiWriter) Write(p []byte) (int, error) {
+	b := append([]byte{}, p...)
+
+	gw.g.Update(func(g *gocui.Gui) error {
+		if _, err := gw.w.Write(b); err != nil {
+			gw.l.Log(err.Error())
+		}
+
+		return nil
+	})
+
+	return len(p), nil
+}
+
+// oscNotifier sends desktop notifications through the terminal using OSC 9
+// (iTerm2, kitty, foot) or OSC 777 (urxvt, vte)
+type oscNotifier struct {
+	w      io.Writer
+	osc777 bool
+}
+
+// oscText removes control characters that would end the escape sequence early
+func oscText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == ';' {
+			return ' '
+		}
+
+		return r
+	}, s)
+}
+
+func (on oscNotifier) Notify(n notification) error {
+	seq := fmt.Sprintf("\x1b]9;%s: %s\a", oscText(n.title()), oscText(n.text))
+
+	if on.osc777 {
+		seq = fmt.Sprintf("\x1b]777;notify;%s;%s\a", oscText(n.title()), oscText(n.text))
+	}
+
+	_, err := io.WriteString(on.w, seq)
+
+	return err
+}
+
+// commandNotifier runs a shell command with the message details in its
+// environment, without waiting for it to finish
+type commandNotifier struct {
+	command string
+	l       *logger
+}
+
+func (cn commandNotifier) env(n notification) []string {
+	kind := "highlight"
+	if n.private {
+		kind = "private"
+	}
+
+	return append(os.Environ(),
+		"NAKO_KIND="+kind,
+		"NAKO_BUFFER="+n.buffer,
+		"NAKO_NICK="+n.nick,
+		"NAKO_MESSAGE="+n.text,
+		"NAKO_TITLE="+n.title(),
+	)
+}
+
+func (cn commandNotifier) Notify(n notification) error {
+	cmd := exec.Command("sh", "-c", cn.command)
+	cmd.Env = cn.env(n)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			cn.l.Log(fmt.Sprintf("notify command: %s", err))
+		}
+	}()
+
+	return nil
+}
+
+// notificationManager sends notifications to every backend, skipping the
+// focused buffer and anything within interval of the last notification
+type notificationManager struct {
+	sync.Mutex
+	notifiers []notifier
+	interval  time.Duration
+	focused   func(buffer string) bool
+	now       func() time.Time
+	last      time.Time
+}
+
+func (nm *notificationManager) Notify(n notification) error {
+	if len(nm.notifiers) == 0 || nm.focused(n.buffer) {
+		return nil
+	}
+
+	nm.Lock()
+	now := nm.now()
+	if !nm.last.IsZero() && now.Sub(nm.last) < nm.interval {
+		nm.Unlock()
+		return nil
+	}
+	nm.last = now
+	nm.Unlock()
+
+	n.text = stripFormatting(n.text)
+
+	errs := []string{}
+
+	for _, nn := range nm.notifiers {
+		if err := nn.Notify(n); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func createNotifier(backend, command string, w io.Writer, l *logger) (notifier, error) {
+	switch backend {
+	case "bell":
+		return bellNotifier{w: w}, nil
+	case "osc9":
+		return oscNotifier{w: w}, nil
+	case "osc777":
+		return oscNotifier{w: w, osc777: true}, nil
+	case "command":
+		if command == "" {
+			return nil, fmt.Errorf("command notifications need a notify command")
+		}
+
+		return commandNotifier{command: command, l: l}, nil
+	}
+
+	return nil, fmt.Errorf("unknown notification backend %q", backend)
+}
+
+// validateNotifiers checks the notification options before the gui starts
+func validateNotifiers(backends []string, command string) error {
+	for _, b := range backends {
+		if _, err := createNotifier(b, command, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createNotificationManager(backends []string, command string, interval time.Duration, w io.Writer, focused func(buffer string) bool, l *logger) (*notificationManager, error) {
+	nm := &notificationManager{
+		interval: interval,
+		focused:  focused,
+		now:      time.Now,
+	}
+
+	for _, b := range backends {
+		n, err := createNotifier(b, command, w, l)
+		if err != nil {
+			return nil, err
+		}
+
+		nm.notifiers = append(nm.notifiers, n)
+	}
+
+	return nm, nil
+}
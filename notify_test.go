@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingNotifier struct {
+	sent []notification
+	err  error
+}
+
+func (rn *recordingNotifier) Notify(n notification) error {
+	rn.sent = append(rn.sent, n)
+
+	return rn.err
+}
+
+func TestNotificationTitle(t *testing.T) {
+	assert.Equal(t, "nako: message from gowon", notification{nick: "gowon", private: true}.title())
+	assert.Equal(t, "nako: gowon highlighted you in #nako", notification{buffer: "#nako", nick: "gowon"}.title())
+}
+
+func TestOscText(t *testing.T) {
+	assert.Equal(t, "a b c d", oscText("a\x1bb\ac;d"))
+}
+
+func TestTerminalNotifiers(t *testing.T) {
+	n := notification{buffer: "#nako", nick: "gowon", text: "hi nako"}
+
+	cases := []struct {
+		name    string
+		backend string
+		out     string
+	}{
+		{
+			name:    "bell",
+			backend: "bell",
+			out:     "\a",
+		},
+		{
+			name:    "osc 9",
+			backend: "osc9",
+			out:     "\x1b]9;nako: gowon highlighted you in #nako: hi nako\a",
+		},
+		{
+			name:    "osc 777",
+			backend: "osc777",
+			out:     "\x1b]777;notify;nako: gowon highlighted you in #nako;hi nako\a",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+
+			nn, err := createNotifier(tc.backend, "", b, nil)
+			assert.Nil(t, err)
+			assert.Nil(t, nn.Notify(n))
+			assert.Equal(t, tc.out, b.String())
+		})
+	}
+}
+
+func TestCommandNotifierEnv(t *testing.T) {
+	env := commandNotifier{}.env(notification{buffer: "gowon", nick: "gowon", text: "hi", private: true})
+
+	assert.Contains(t, env, "NAKO_KIND=private")
+	assert.Contains(t, env, "NAKO_BUFFER=gowon")
+	assert.Contains(t, env, "NAKO_NICK=gowon")
+	assert.Contains(t, env, "NAKO_MESSAGE=hi")
+	assert.Contains(t, env, "NAKO_TITLE=nako: message from gowon")
+}
+
+func TestCreateNotifier(t *testing.T) {
+	for _, b := range []string{"bell", "osc9", "osc777"} {
+		_, err := createNotifier(b, "", &bytes.Buffer{}, nil)
+		assert.Nil(t, err)
+	}
+
+	_, err := createNotifier("command", "", &bytes.Buffer{}, nil)
+	assert.NotNil(t, err)
+
+	_, err = createNotifier("command", "notify-send \"$NAKO_TITLE\"", &bytes.Buffer{}, nil)
+	assert.Nil(t, err)
+
+	_, err = createNotifier("pigeon", "", &bytes.Buffer{}, nil)
+	assert.NotNil(t, err)
+
+	_, err = createNotificationManager([]string{"bell", "pigeon"}, "", time.Second, &bytes.Buffer{}, nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestNotificationManager(t *testing.T) {
+	rn := &recordingNotifier{}
+	now := time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)
+
+	nm := &notificationManager{
+		notifiers: []notifier{rn},
+		interval:  10 * time.Second,
+		focused: func(buffer string) bool {
+			return buffer == "#nako"
+		},
+		now: func() time.Time {
+			return now
+		},
+	}
+
+	assert.Nil(t, nm.Notify(notification{buffer: "#nako", text: "focused"}))
+	assert.Nil(t, nm.Notify(notification{buffer: "#gowon", text: "\x02first\x02"}))
+
+	now = now.Add(5 * time.Second)
+	assert.Nil(t, nm.Notify(notification{buffer: "#gowon", text: "rate limited"}))
+
+	now = now.Add(5 * time.Second)
+	assert.Nil(t, nm.Notify(notification{buffer: "#gowon", text: "second"}))
+
+	assert.Equal(t, []notification{
+		{buffer: "#gowon", text: "first"},
+		{buffer: "#gowon", text: "second"},
+	}, rn.sent)
+
+	rn.err = errors.New("failed")
+	now = now.Add(time.Minute)
+	assert.NotNil(t, nm.Notify(notification{buffer: "#gowon"}))
+}
+
+func TestNotificationManagerErrors(t *testing.T) {
+	failing := &recordingNotifier{err: errors.New("first failed")}
+	rn := &recordingNotifier{}
+	other := &recordingNotifier{err: errors.New("last failed")}
+
+	nm := &notificationManager{
+		notifiers: []notifier{failing, rn, other},
+		focused: func(buffer string) bool {
+			return false
+		},
+		now: time.Now,
+	}
+
+	err := nm.Notify(notification{buffer: "#gowon", text: "hi"})
+	assert.EqualError(t, err, "first failed; last failed")
+	assert.Equal(t, []notification{{buffer: "#gowon", text: "hi"}}, rn.sent)
+}
+
+func TestValidateNotifiers(t *testing.T) {
+	assert.Nil(t, validateNotifiers([]string{"bell", "osc9"}, ""))
+	assert.NotNil(t, validateNotifiers([]string{"command"}, ""))
+	assert.NotNil(t, validateNotifiers([]string{"popup"}, ""))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+var errorExplanations = map[string]string{
+	"401": "no such nick or channel",
+	"402": "no such server",
+	"403": "no such channel",
+	"404": "cannot send to channel, you may need voice or be banned",
+	"405": "you have joined too many channels",
+	"406": "there was no such nick",
+	"421": "the server does not know that command",
+	"432": "that nick is not allowed",
+	"433": "that nick is already in use",
+	"436": "that nick collided with another server's",
+	"437": "that nick or channel is temporarily unavailable",
+	"441": "they are not in that channel",
+	"442": "you are not in that channel",
+	"443": "they are already in that channel",
+	"451": "you have not registered with the server yet",
+	"461": "the command is missing parameters",
+	"462": "you are already registered",
+	"464": "the server password is wrong",
+	"465": "you are banned from the server",
+	"471": "the channel is full",
+	"472": "the server does not know that mode",
+	"473": "the channel is invite only",
+	"474": "you are banned from the channel",
+	"475": "the channel key is wrong",
+	"477": "the channel needs a registered nick",
+	"478": "the channel ban list is full",
+	"481": "you need to be an IRC operator",
+	"482": "you need to be a channel operator",
+	"501": "the server does not know that user mode",
+	"502": "you can only change your own user modes",
+}
+
+func isErrorNumeric(code string) bool {
+	return len(code) == 3 && (code[0] == '4' || code[0] == '5') && stringIsNumber(code)
+}
+
+// errorChannel finds the channel an error numeric is about, skipping our own
+// nick and the server's message
+func errorChannel(args []string) (string, bool) {
+	if len(args) < 3 {
+		return "", false
+	}
+
+	for _, a := range args[1 : len(args)-1] {
+		if isChannel(a) {
+			return a, true
+		}
+	}
+
+	return "", false
+}
+
+// formatErrorNumeric shows the subject of an error numeric, without our own
+// nick, then an explanation of the code and the server's message
+func formatErrorNumeric(code string, args []string) string {
+	subject, text := "", ""
+
+	if len(args) > 1 {
+		text = args[len(args)-1]
+	}
+
+	if len(args) > 2 {
+		subject = strings.Join(args[1:len(args)-1], " ") + ": "
+	}
+
+	if explanation, p := errorExplanations[code]; p {
+		text = withReason(explanation, text)
+	}
+
+	return fmt.Sprintf("error %s: %s%s", code, subject, text)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsErrorNumeric(t *testing.T) {
+	assert.True(t, isErrorNumeric("482"))
+	assert.True(t, isErrorNumeric("502"))
+	assert.False(t, isErrorNumeric("332"))
+	assert.False(t, isErrorNumeric("4x2"))
+	assert.False(t, isErrorNumeric("NOTICE"))
+}
+
+func TestErrorChannel(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		channel string
+		ok      bool
+	}{
+		{
+			name:    "channel subject",
+			args:    []string{"nako", "#nako", "You're not channel operator"},
+			channel: "#nako",
+			ok:      true,
+		},
+		{
+			name:    "nick and channel subject",
+			args:    []string{"nako", "gowon", "#nako", "They aren't on that channel"},
+			channel: "#nako",
+			ok:      true,
+		},
+		{
+			name: "nick subject",
+			args: []string{"nako", "gowon", "No such nick/channel"},
+		},
+		{
+			name: "channel in message",
+			args: []string{"nako", "#nako is not a command"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			channel, ok := errorChannel(tc.args)
+			assert.Equal(t, tc.channel, channel)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestFormatErrorNumeric(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+		args []string
+		out  string
+	}{
+		{
+			name: "known code",
+			code: "482",
+			args: []string{"nako", "#nako", "You're not channel operator"},
+			out:  "error 482: #nako: you need to be a channel operator (You're not channel operator)",
+		},
+		{
+			name: "two subjects",
+			code: "441",
+			args: []string{"nako", "gowon", "#nako", "They aren't on that channel"},
+			out:  "error 441: gowon #nako: they are not in that channel (They aren't on that channel)",
+		},
+		{
+			name: "unknown code",
+			code: "499",
+			args: []string{"nako", "#nako", "Something went wrong"},
+			out:  "error 499: #nako: Something went wrong",
+		},
+		{
+			name: "message only",
+			code: "451",
+			args: []string{"*", "You have not registered"},
+			out:  "error 451: you have not registered with the server yet (You have not registered)",
+		},
+		{
+			name: "no arguments",
+			code: "499",
+			args: []string{},
+			out:  "error 499: ",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, formatErrorNumeric(tc.code, tc.args))
+		})
+	}
+}
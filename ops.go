@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// modesPerLine is the number of mode changes servers accept in one MODE
+// command when they don't advertise MODES
+const modesPerLine = 3
+
+var channelCommandUsage = map[string]string{
+	"t":       "/t [text]",
+	"topic":   "/topic [text]",
+	"kick":    "/kick <nick> [reason]",
+	"ban":     "/ban <nick|mask> [nick|mask...]",
+	"unban":   "/unban <nick|mask> [nick|mask...]",
+	"op":      "/op <nick> [nick...]",
+	"deop":    "/deop <nick> [nick...]",
+	"voice":   "/voice <nick> [nick...]",
+	"devoice": "/devoice <nick> [nick...]",
+	"mode":    "/mode [target] <modes> [args...]",
+}
+
+var memberModeCommands = map[string]string{
+	"op":      "+o",
+	"deop":    "-o",
+	"voice":   "+v",
+	"devoice": "-v",
+}
+
+// hostCache remembers the host last seen for each nick, so bans can be set
+// without looking the nick up first
+type hostCache struct {
+	sync.Mutex
+	hosts map[string]string
+}
+
+func (hc *hostCache) Seen(nick, host string) {
+	if nick == "" || host == "" {
+		return
+	}
+
+	hc.Lock()
+	defer hc.Unlock()
+
+	hc.hosts[strings.ToLower(nick)] = host
+}
+
+func (hc *hostCache) Nick(old, new string) {
+	hc.Lock()
+	defer hc.Unlock()
+
+	host, p := hc.hosts[strings.ToLower(old)]
+	if !p {
+		return
+	}
+
+	delete(hc.hosts, strings.ToLower(old))
+	hc.hosts[strings.ToLower(new)] = host
+}
+
+func (hc *hostCache) Host(nick string) (string, bool) {
+	hc.Lock()
+	defer hc.Unlock()
+
+	host, p := hc.hosts[strings.ToLower(nick)]
+
+	return host, p
+}
+
+func createHostCache() *hostCache {
+	return &hostCache{
+		hosts: make(map[string]string),
+	}
+}
+
+// banMask bans a nick by its known host, falling back to the nick itself when
+// the host is unknown. Targets that are already masks are used as they are.
+func banMask(target string, hc *hostCache) string {
+	if strings.ContainsAny(target, "!@*") {
+		return target
+	}
+
+	if host, ok := hc.Host(target); ok {
+		return "*!*@" + host
+	}
+
+	return target + "!*@*"
+}
+
+// formatMemberModeCommands sets one mode on each of the targets, eg +oo a b,
+// splitting them over as many MODE commands as the server needs
+func formatMemberModeCommands(channel, mode string, targets []string) []string {
+	commands := []string{}
+
+	for i := 0; i < len(targets); i += modesPerLine {
+		chunk := targets[i:]
+		if len(chunk) > modesPerLine {
+			chunk = chunk[:modesPerLine]
+		}
+
+		modes := mode[:1] + strings.Repeat(mode[1:], len(chunk))
+		commands = append(commands, fmt.Sprintf("MODE %s %s %s", channel, modes, strings.Join(chunk, " ")))
+	}
+
+	return commands
+}
+
+// hasModeTarget reports whether /mode arguments start with a target rather
+// than a mode change
+func hasModeTarget(args []string) bool {
+	return len(args) > 0 && !strings.HasPrefix(args[0], "+") && !strings.HasPrefix(args[0], "-")
+}
+
+// formatModeCommand targets the channel unless the first argument names a
+// different target
+func formatModeCommand(channel string, args []string) string {
+	if hasModeTarget(args) {
+		return "MODE " + strings.Join(args, " ")
+	}
+
+	return strings.TrimSpace("MODE " + channel + " " + strings.Join(args, " "))
+}
+
+func formatKickCommand(channel, nick, reason string) string {
+	if reason == "" {
+		return fmt.Sprintf("KICK %s %s", channel, nick)
+	}
+
+	return fmt.Sprintf("KICK %s %s :%s", channel, nick, reason)
+}
+
+func formatTopicCommand(channel, text string) string {
+	if text == "" {
+		return "TOPIC " + channel
+	}
+
+	return fmt.Sprintf("TOPIC %s :%s", channel, text)
+}
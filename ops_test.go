@@ -0,0 +1,150 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostCache(t *testing.T) {
+	hc := createHostCache()
+
+	hc.Seen("Gowon", "example.com")
+	hc.Seen("nako", "")
+
+	host, ok := hc.Host("gowon")
+	assert.True(t, ok)
+	assert.Equal(t, "example.com", host)
+
+	_, ok = hc.Host("nako")
+	assert.False(t, ok)
+
+	hc.Nick("gowon", "gowon2")
+
+	_, ok = hc.Host("gowon")
+	assert.False(t, ok)
+
+	host, ok = hc.Host("gowon2")
+	assert.True(t, ok)
+	assert.Equal(t, "example.com", host)
+}
+
+func TestBanMask(t *testing.T) {
+	hc := createHostCache()
+	hc.Seen("gowon", "example.com")
+
+	cases := []struct {
+		name   string
+		target string
+		out    string
+	}{
+		{
+			name:   "known host",
+			target: "gowon",
+			out:    "*!*@example.com",
+		},
+		{
+			name:   "unknown host",
+			target: "nako",
+			out:    "nako!*@*",
+		},
+		{
+			name:   "mask",
+			target: "*!*@other.com",
+			out:    "*!*@other.com",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, banMask(tc.target, hc))
+		})
+	}
+}
+
+func TestFormatMemberModeCommands(t *testing.T) {
+	cases := []struct {
+		name    string
+		mode    string
+		targets []string
+		out     []string
+	}{
+		{
+			name:    "one target",
+			mode:    "+o",
+			targets: []string{"gowon"},
+			out:     []string{"MODE #nako +o gowon"},
+		},
+		{
+			name:    "several targets",
+			mode:    "-v",
+			targets: []string{"a", "b"},
+			out:     []string{"MODE #nako -vv a b"},
+		},
+		{
+			name:    "split over lines",
+			mode:    "+b",
+			targets: []string{"a!*@*", "b!*@*", "c!*@*", "d!*@*"},
+			out:     []string{"MODE #nako +bbb a!*@* b!*@* c!*@*", "MODE #nako +b d!*@*"},
+		},
+		{
+			name:    "no targets",
+			mode:    "+o",
+			targets: []string{},
+			out:     []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, formatMemberModeCommands("#nako", tc.mode, tc.targets))
+		})
+	}
+}
+
+func TestHasModeTarget(t *testing.T) {
+	assert.True(t, hasModeTarget([]string{"#nako", "+m"}))
+	assert.True(t, hasModeTarget([]string{"nako", "+i"}))
+	assert.False(t, hasModeTarget([]string{"+m"}))
+	assert.False(t, hasModeTarget([]string{}))
+}
+
+func TestFormatModeCommand(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		out  string
+	}{
+		{
+			name: "query channel modes",
+			args: []string{},
+			out:  "MODE #nako",
+		},
+		{
+			name: "set channel modes",
+			args: []string{"+l", "10"},
+			out:  "MODE #nako +l 10",
+		},
+		{
+			name: "other target",
+			args: []string{"nako", "+i"},
+			out:  "MODE nako +i",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, formatModeCommand("#nako", tc.args))
+		})
+	}
+}
+
+func TestFormatKickCommand(t *testing.T) {
+	assert.Equal(t, "KICK #nako gowon", formatKickCommand("#nako", "gowon", ""))
+	assert.Equal(t, "KICK #nako gowon :be nice", formatKickCommand("#nako", "gowon", "be nice"))
+}
+
+func TestFormatTopicCommand(t *testing.T) {
+	assert.Equal(t, "TOPIC #nako", formatTopicCommand("#nako", ""))
+	assert.Equal(t, "TOPIC #nako :new topic", formatTopicCommand("#nako", "new topic"))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+var destructiveVerbs = []string{"QUIT", "SQUIT", "KILL", "DIE", "RESTART"}
+
+// rawLine returns what follows /raw or /quote exactly as typed, only dropping
+// the space separating it from the command
+func rawLine(input, command string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(input, "/"+command), " ")
+}
+
+// rawVerb returns the command of a raw line, skipping any leading tags and
+// prefix
+func rawVerb(line string) string {
+	fields := strings.Fields(line)
+
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+		fields = fields[1:]
+	}
+
+	if len(fields) > 0 && strings.HasPrefix(fields[0], ":") {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return strings.ToUpper(fields[0])
+}
+
+// rawConfirmer holds back destructive raw lines until they are sent a second
+// time in a row
+type rawConfirmer struct {
+	sync.Mutex
+	pending string
+}
+
+func (rc *rawConfirmer) Confirm(line string) bool {
+	rc.Lock()
+	defer rc.Unlock()
+
+	if !containsString(destructiveVerbs, rawVerb(line)) || rc.pending == line {
+		rc.pending = ""
+		return true
+	}
+
+	rc.pending = line
+
+	return false
+}
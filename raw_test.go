@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawLine(t *testing.T) {
+	assert.Equal(t, "PRIVMSG #nako :hi  ", rawLine("/raw PRIVMSG #nako :hi  ", "raw"))
+	assert.Equal(t, " WHO #nako", rawLine("/quote  WHO #nako", "quote"))
+	assert.Equal(t, "", rawLine("/raw", "raw"))
+}
+
+func TestRawVerb(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+		verb string
+	}{
+		{
+			name: "verb",
+			line: "quit :bye",
+			verb: "QUIT",
+		},
+		{
+			name: "leading spaces",
+			line: "  PRIVMSG #nako :hi",
+			verb: "PRIVMSG",
+		},
+		{
+			name: "tags",
+			line: "@label=a QUIT",
+			verb: "QUIT",
+		},
+		{
+			name: "prefix",
+			line: ":nako QUIT :bye",
+			verb: "QUIT",
+		},
+		{
+			name: "tags and prefix",
+			line: "@a=b :nako quit",
+			verb: "QUIT",
+		},
+		{
+			name: "only tags",
+			line: "@a=b",
+			verb: "",
+		},
+		{
+			name: "empty",
+			line: "",
+			verb: "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.verb, rawVerb(tc.line))
+		})
+	}
+}
+
+func TestRawConfirmer(t *testing.T) {
+	rc := &rawConfirmer{}
+
+	assert.True(t, rc.Confirm("PRIVMSG #nako :hi"))
+
+	assert.False(t, rc.Confirm("QUIT :bye"))
+	assert.True(t, rc.Confirm("QUIT :bye"))
+
+	assert.False(t, rc.Confirm("QUIT :bye"))
+	assert.True(t, rc.Confirm("WHO #nako"))
+	assert.False(t, rc.Confirm("QUIT :bye"))
+
+	assert.False(t, rc.Confirm("quit :later"))
+	assert.True(t, rc.Confirm("quit :later"))
+
+	assert.False(t, rc.Confirm("@a=b :nako QUIT"))
+	assert.True(t, rc.Confirm("@a=b :nako QUIT"))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const maxScrollbackLines = 5000
+
+type scrollbackLine struct {
+	at   time.Time
+	text string
+}
+
+// scrollback keeps the rendered lines of each buffer so lines arriving out of
+// order, such as chat history, can be inserted and the chat view redrawn
+type scrollback struct {
+	sync.Mutex
+	buffers map[string][]scrollbackLine
+	redraw  func(buffer string)
+}
+
+func trimScrollback(lines []scrollbackLine) []scrollbackLine {
+	if len(lines) > maxScrollbackLines {
+		return lines[len(lines)-maxScrollbackLines:]
+	}
+
+	return lines
+}
+
+func (sb *scrollback) Append(buffer, text string, at time.Time) {
+	sb.Lock()
+	defer sb.Unlock()
+
+	sb.buffers[buffer] = trimScrollback(append(sb.buffers[buffer], scrollbackLine{at: at, text: text}))
+}
+
+// Insert places each line after any existing lines with the same or an
+// earlier time, then redraws the buffer
+func (sb *scrollback) Insert(buffer string, lines []scrollbackLine) {
+	if len(lines) == 0 {
+		return
+	}
+
+	sb.Lock()
+
+	existing := sb.buffers[buffer]
+
+	for _, l := range lines {
+		i := len(existing)
+		for i > 0 && existing[i-1].at.After(l.at) {
+			i--
+		}
+
+		existing = append(existing, scrollbackLine{})
+		copy(existing[i+1:], existing[i:])
+		existing[i] = l
+	}
+
+	sb.buffers[buffer] = trimScrollback(existing)
+
+	sb.Unlock()
+
+	sb.redraw(buffer)
+}
+
+func (sb *scrollback) Lines(buffer string) []string {
+	sb.Lock()
+	defer sb.Unlock()
+
+	lines := []string{}
+	for _, l := range sb.buffers[buffer] {
+		lines = append(lines, l.text)
+	}
+
+	return lines
+}
+
+func (sb *scrollback) Clear(buffer string) {
+	sb.Lock()
+	defer sb.Unlock()
+
+	delete(sb.buffers, buffer)
+}
+
+func createScrollback(redraw func(buffer string)) *scrollback {
+	return &scrollback{
+		buffers: make(map[string][]scrollbackLine),
+		redraw:  redraw,
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrollbackAppendClear(t *testing.T) {
+	sb := createScrollback(func(buffer string) {})
+	now := time.Now()
+
+	sb.Append("#nako", "a", now)
+	sb.Append("#nako", "b", now)
+	sb.Append("#gowon", "c", now)
+
+	assert.Equal(t, []string{"a", "b"}, sb.Lines("#nako"))
+	assert.Equal(t, []string{"c"}, sb.Lines("#gowon"))
+
+	sb.Clear("#nako")
+	assert.Equal(t, []string{}, sb.Lines("#nako"))
+}
+
+func TestScrollbackInsert(t *testing.T) {
+	redrawn := []string{}
+	sb := createScrollback(func(buffer string) {
+		redrawn = append(redrawn, buffer)
+	})
+
+	base := time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)
+
+	sb.Append("#nako", "b", base.Add(2*time.Minute))
+	sb.Append("#nako", "d", base.Add(4*time.Minute))
+
+	sb.Insert("#nako", []scrollbackLine{})
+	assert.Equal(t, []string{}, redrawn)
+
+	sb.Insert("#nako", []scrollbackLine{
+		{at: base, text: "a"},
+		{at: base.Add(2 * time.Minute), text: "b2"},
+		{at: base.Add(3 * time.Minute), text: "c"},
+		{at: base.Add(5 * time.Minute), text: "e"},
+	})
+
+	assert.Equal(t, []string{"a", "b", "b2", "c", "d", "e"}, sb.Lines("#nako"))
+	assert.Equal(t, []string{"#nako"}, redrawn)
+}
+
+func TestScrollbackTrim(t *testing.T) {
+	sb := createScrollback(func(buffer string) {})
+
+	for i := 0; i < maxScrollbackLines+1; i++ {
+		sb.Append("#nako", "line", time.Now())
+	}
+
+	assert.Len(t, sb.Lines("#nako"), maxScrollbackLines)
+}
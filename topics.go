@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type topic struct {
+	text  string
+	setBy string
+	setAt time.Time
+}
+
+type topicStore struct {
+	sync.Mutex
+	topics map[string]topic
+}
+
+func (ts *topicStore) SetText(channel, text string) {
+	ts.Lock()
+	defer ts.Unlock()
+
+	t := ts.topics[channel]
+	t.text = text
+	ts.topics[channel] = t
+}
+
+func (ts *topicStore) SetWho(channel, setBy string, setAt time.Time) {
+	ts.Lock()
+	defer ts.Unlock()
+
+	t := ts.topics[channel]
+	t.setBy = setBy
+	t.setAt = setAt
+	ts.topics[channel] = t
+}
+
+func (ts *topicStore) Set(channel string, t topic) {
+	ts.Lock()
+	defer ts.Unlock()
+
+	ts.topics[channel] = t
+}
+
+func (ts *topicStore) Get(channel string) topic {
+	ts.Lock()
+	defer ts.Unlock()
+
+	return ts.topics[channel]
+}
+
+func createTopicStore() *topicStore {
+	return &topicStore{
+		topics: make(map[string]topic),
+	}
+}
+
+func parseUnixTime(s string) (time.Time, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(i, 0), nil
+}
+
+func formatTopic(t topic, ck *clock) string {
+	out := ircToAnsiColours(t.text)
+
+	if t.setBy == "" {
+		return out
+	}
+
+	if t.setAt.IsZero() {
+		return fmt.Sprintf("%s\x1b[0m (set by %s)", out, t.setBy)
+	}
+
+	return fmt.Sprintf("%s\x1b[0m (set by %s on %s)", out, t.setBy, ck.Date(t.setAt)+" "+ck.Format(t.setAt))
+}
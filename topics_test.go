@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicStore(t *testing.T) {
+	ts := createTopicStore()
+	setAt := time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)
+
+	assert.Equal(t, topic{}, ts.Get("#nako"))
+
+	ts.SetText("#nako", "hello")
+	ts.SetWho("#nako", "gowon", setAt)
+	assert.Equal(t, topic{text: "hello", setBy: "gowon", setAt: setAt}, ts.Get("#nako"))
+
+	ts.SetText("#nako", "goodbye")
+	assert.Equal(t, topic{text: "goodbye", setBy: "gowon", setAt: setAt}, ts.Get("#nako"))
+
+	ts.Set("#nako", topic{})
+	assert.Equal(t, topic{}, ts.Get("#nako"))
+}
+
+func TestParseUnixTime(t *testing.T) {
+	got, err := parseUnixTime("1658231580")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1658231580), got.Unix())
+
+	_, err = parseUnixTime("nako")
+	assert.NotNil(t, err)
+}
+
+func TestFormatTopic(t *testing.T) {
+	cases := []struct {
+		name string
+		in   topic
+		ck   *clock
+		out  string
+	}{
+		{
+			name: "empty topic",
+			in:   topic{},
+			out:  "",
+		},
+		{
+			name: "text only",
+			in:   topic{text: "hello"},
+			out:  "hello",
+		},
+		{
+			name: "text and setter",
+			in:   topic{text: "hello", setBy: "gowon"},
+			out:  "hello\x1b[0m (set by gowon)",
+		},
+		{
+			name: "text, setter and time",
+			in:   topic{text: "hello", setBy: "gowon", setAt: time.Date(2022, 7, 19, 11, 53, 0, 0, time.UTC)},
+			out:  "hello\x1b[0m (set by gowon on 2022-07-19 11:53)",
+		},
+		{
+			name: "time in the clock's zone and layout",
+			in:   topic{text: "hello", setBy: "gowon", setAt: time.Date(2022, 7, 19, 23, 53, 0, 0, time.UTC)},
+			ck:   &clock{loc: time.FixedZone("UTC+1", 60*60), layout: "3:04PM"},
+			out:  "hello\x1b[0m (set by gowon on 2022-07-20 12:53AM)",
+		},
+		{
+			name: "coloured text",
+			in:   topic{text: "\u000304hello"},
+			out:  "\x1b[31mhello",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ck := tc.ck
+			if ck == nil {
+				ck = testClock()
+			}
+
+			got := formatTopic(tc.in, ck)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
@@ -11,56 +11,230 @@ import (
 	"github.com/gowon-irc/go-gowon"
 )
 
-func genLayout(channels []string) func(g *gocui.Gui) error {
+func setChatView(g *gocui.Gui, buffer string, x0, y0, x1, y1 int) (*gocui.View, error) {
+	v, err := g.SetView(chatViewName(buffer), x0, y0, x1, y1, gocui.TOP)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) {
+			return nil, err
+		}
+
+		v.Autoscroll = true
+		v.Wrap = true
+		v.Frame = false
+		v.Visible = false
+	}
+
+	return v, nil
+}
+
+func getChatView(g *gocui.Gui, buffer string) (*gocui.View, error) {
+	v, err := g.View(chatViewName(buffer))
+	if errors.Is(err, gocui.ErrUnknownView) {
+		return setChatView(g, buffer, 0, 0, 1, 1)
+	}
+
+	return v, err
+}
+
+type toggle struct {
+	on bool
+}
+
+func redrawChatView(g *gocui.Gui, sb *scrollback, buffer string) error {
+	v, err := getChatView(g, buffer)
+	if err != nil {
+		return err
+	}
+
+	ox, oy := v.Origin()
+	before := v.LinesHeight()
+
+	v.Clear()
+
+	for _, l := range sb.Lines(buffer) {
+		fmt.Fprintln(v, l)
+	}
+
+	if v.Autoscroll {
+		return nil
+	}
+
+	// keep the same lines in view when history is inserted above them
+	return v.SetOrigin(ox, oy+v.LinesHeight()-before)
+}
+
+func genToggle(t *toggle) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		t.on = !t.on
+
+		return nil
+	}
+}
+
+func nickListWidth(members []string) int {
+	w := 0
+
+	for _, m := range members {
+		if len(m) > w {
+			w = len(m)
+		}
+	}
+
+	return w + 2
+}
+
+func setPaneView(g *gocui.Gui, name string, x0, y0, x1, y1 int) (*gocui.View, error) {
+	v, err := g.SetView(name, x0, y0, x1, y1, gocui.TOP)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) {
+			return nil, err
+		}
+
+		v.Frame = false
+	}
+
+	v.Clear()
+
+	return v, nil
+}
+
+func layoutPreview(g *gocui.Gui, x0, y, x1 int, text string) error {
+	if text == "" {
+		if err := g.DeleteView("preview"); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
+
+		return nil
+	}
+
+	v, err := setPaneView(g, "preview", x0, y-1, x1, y+1)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(v, text)
+
+	if _, err := g.SetViewOnTop("preview"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func genLayout(bl *bufferList, at *activityTracker, ml *memberList, ts *topicStore, showNames, showPalette *toggle, markup bool, th *theme, ca *colourAllocator, ck *clock) func(g *gocui.Gui) error {
 	return func(g *gocui.Gui) error {
 		maxX, maxY := g.Size()
 
-		chatMaxY := maxY
-		initialView := "chat"
+		chatMinY := 0
+		chatMaxY := maxY - 2
+		active := bl.Active()
+		buffers := bl.Names()
+		chatMinX := sidebarWidth(buffers)
+		chatMaxX := maxX
+		members := ml.Members(active)
 
-		if len(channels) == 1 {
-			chatMaxY = maxY - 2
-			initialView = "entry"
+		if showNames.on {
+			chatMaxX = maxX - nickListWidth(members)
 
-			if v, err := g.SetView("channel", 0, chatMaxY, len(channels[0])+2, maxY, gocui.TOP); err != nil {
-				if !errors.Is(err, gocui.ErrUnknownView) {
-					return err
-				}
+			nv, err := setPaneView(g, "names", chatMaxX, -1, maxX, chatMaxY)
+			if err != nil {
+				return err
+			}
 
-				v.Frame = false
-				v.FgColor = gocui.ColorGreen
+			nv.FgColor = th.nickList
 
-				fmt.Fprint(v, channels[0]+":")
+			for _, m := range members {
+				fmt.Fprintln(nv, colourName(m, ca))
 			}
+		} else if err := g.DeleteView("names"); err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
 
-			if v, err := g.SetView("entry", len(channels[0])+2, chatMaxY, maxX, maxY, gocui.TOP); err != nil {
-				if !errors.Is(err, gocui.ErrUnknownView) {
-					return err
-				}
+		at.Clear(active)
 
-				v.Frame = false
-				v.Editable = true
-				v.Wrap = true
+		sv, err := setPaneView(g, "sidebar", 0, -1, chatMinX, chatMaxY)
+		if err != nil {
+			return err
+		}
 
-				g.Cursor = true
-			}
+		sv.FgColor = th.sidebar
+
+		for _, b := range buffers {
+			fmt.Fprintln(sv, formatSidebarEntry(b, b == active, at.Get(b)))
 		}
 
-		if v, err := g.SetView("chat", 0, -1, maxX, chatMaxY, gocui.TOP); err != nil {
+		tv, err := setPaneView(g, "topic", chatMinX, -1, chatMaxX, chatMinY+1)
+		if err != nil {
+			return err
+		}
+
+		tv.FgColor = th.topic
+		fmt.Fprint(tv, formatTopic(ts.Get(active), ck))
+
+		v, err := setPaneView(g, "channel", 0, chatMaxY, len(active)+2, maxY)
+		if err != nil {
+			return err
+		}
+
+		v.FgColor = th.prompt
+		fmt.Fprint(v, active+":")
+
+		ev, err := g.SetView("entry", len(active)+2, chatMaxY, maxX, maxY, gocui.TOP)
+		if err != nil {
 			if !errors.Is(err, gocui.ErrUnknownView) {
 				return err
 			}
 
-			v.Autoscroll = true
-			v.Wrap = true
-			v.Frame = false
+			ev.Frame = false
+			ev.Editable = true
+			ev.Editor = genEntryEditor(showPalette)
+			ev.Wrap = true
+			ev.KeybindOnEdit = true
+
+			g.Cursor = true
 
-			if _, err := g.SetCurrentView(initialView); err != nil {
+			if _, err := g.SetCurrentView("entry"); err != nil {
 				return err
 			}
 		}
 
-		return nil
+		for _, b := range buffers {
+			v, err := setChatView(g, b, chatMinX, chatMinY, chatMaxX, chatMaxY)
+			if err != nil {
+				return err
+			}
+
+			v.Visible = b == active
+		}
+
+		// drop the views of parted buffers
+		parted := []string{}
+		for _, v := range g.Views() {
+			if isChatView(v.Name()) && !containsString(buffers, strings.TrimPrefix(v.Name(), chatViewPrefix)) {
+				parted = append(parted, v.Name())
+			}
+		}
+
+		for _, name := range parted {
+			if err := g.DeleteView(name); err != nil {
+				return err
+			}
+		}
+
+		preview := ""
+		entry := ev.Buffer()
+
+		if markup {
+			entry = convertMarkup(entry)
+		}
+
+		if showPalette.on {
+			preview = formatPalette()
+		} else if hasIrcFormatting(entry) {
+			preview = ircToAnsiColours(entry)
+		}
+
+		return layoutPreview(g, chatMinX, chatMaxY-1, chatMaxX, preview)
 	}
 }
 
@@ -78,27 +252,127 @@ func entrySwitch(g *gocui.Gui, v *gocui.View) error {
 	return nil
 }
 
-func chatSwitch(g *gocui.Gui, v *gocui.View) error {
-	if _, err := g.SetCurrentView("chat"); err != nil {
-		return err
+func genChatSwitch(bl *bufferList) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if _, err := g.SetCurrentView(chatViewName(bl.Active())); err != nil {
+			return err
+		}
+
+		g.Cursor = false
+
+		return nil
 	}
+}
 
-	g.Cursor = false
+func focusActiveBuffer(g *gocui.Gui, v *gocui.View, bl *bufferList) error {
+	if v == nil || !isChatView(v.Name()) {
+		return nil
+	}
+
+	_, err := g.SetCurrentView(chatViewName(bl.Active()))
+	if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
 
 	return nil
 }
 
+func genBufferCycle(bl *bufferList, d int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		bl.Cycle(d)
+
+		return focusActiveBuffer(g, v, bl)
+	}
+}
+
+func genBufferSelect(bl *bufferList, i int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if !bl.Select(i) {
+			return nil
+		}
+
+		return focusActiveBuffer(g, v, bl)
+	}
+}
+
+func genEntryEditor(showPalette *toggle) gocui.Editor {
+	return gocui.EditorFunc(func(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+		if ch != ',' && (ch < '0' || ch > '9') {
+			showPalette.on = false
+		}
+
+		gocui.DefaultEditor.Edit(v, key, ch, mod)
+	})
+}
+
+func genInsertCode(r rune) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		v.EditWrite(r)
+
+		return nil
+	}
+}
+
+func genColourPicker(showPalette *toggle) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		v.EditWrite(ircColour)
+		showPalette.on = true
+
+		return nil
+	}
+}
+
 func entryClear(g *gocui.Gui, v *gocui.View) error {
 	v.Clear()
 
 	return nil
 }
 
-func genSendMessage(c mqtt.Client, module, topicRoot, channel string, l *logger) func(g *gocui.Gui, v *gocui.View) error {
+func setEntryText(v *gocui.View, s string) {
+	v.Clear()
+	fmt.Fprint(v, s)
+	v.SetCursor(len([]rune(s)), 0)
+}
+
+func genEntryReplace(bl *bufferList, replace func(buffer, current string) (string, bool)) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if s, ok := replace(bl.Active(), v.Buffer()); ok {
+			setEntryText(v, s)
+		}
+
+		return nil
+	}
+}
+
+func genSendMessage(c mqtt.Client, module, topicRoot string, markup bool, chans *channelSet, bl *bufferList, ml *memberList, sb *scrollback, h *inputHistory, il *ignoreList, hc *hostCache, wt *whoisTracker, rc *rawConfirmer, l *logger) func(g *gocui.Gui, v *gocui.View) error {
 	inputTopic := topicRoot + "/input"
 	outputTopic := topicRoot + "/output"
 	rawOutputTopic := topicRoot + "/raw/output"
 
+	publish := func(dest, text string) error {
+		if markup {
+			text = convertMarkup(text)
+		}
+
+		m := &gowon.Message{
+			Module: module,
+			Nick:   ownMessageNick,
+			Dest:   dest,
+			Msg:    text,
+		}
+
+		mj, err := json.Marshal(m)
+		if err != nil {
+			l.Log(err.Error())
+			return err
+		}
+
+		c.Publish(inputTopic, 0, false, mj)
+		c.Publish(outputTopic, 0, false, mj)
+
+		return nil
+	}
+
 	return func(g *gocui.Gui, v *gocui.View) error {
 		b := v.Buffer()
 
@@ -108,8 +382,124 @@ func genSendMessage(c mqtt.Client, module, topicRoot, channel string, l *logger)
 
 		v.Clear()
 
+		channel := bl.Active()
 		command, args := getCommand(b)
 
+		h.Add(channel, b)
+		if err := h.Save(); err != nil {
+			l.Log(err.Error())
+		}
+
+		if command == "c" || command == "clear" {
+			g.Update(func(g *gocui.Gui) error {
+				vc, err := getChatView(g, channel)
+				if err != nil {
+					return err
+				}
+
+				vc.Clear()
+				sb.Clear(channel)
+
+				return nil
+			})
+
+			return nil
+		}
+
+		if command == "ignore" || command == "unignore" {
+			runIgnoreCommand(command, getCommandText(b), il, bl.Logger(channel))
+			return nil
+		}
+
+		if command == "raw" || command == "quote" {
+			line := rawLine(b, command)
+			if strings.TrimSpace(line) == "" {
+				bl.Logger(channel).Log(fmt.Sprintf("usage: /%s <line>", command))
+				return nil
+			}
+
+			if !rc.Confirm(line) {
+				bl.Logger(channel).Log(fmt.Sprintf("%s is destructive, send it again to confirm", rawVerb(line)))
+				return nil
+			}
+
+			c.Publish(rawOutputTopic, 0, false, line)
+			l.Log("-> " + line)
+			return nil
+		}
+
+		if command == "whois" || command == "whowas" {
+			if len(args) == 0 {
+				bl.Logger(channel).Log(fmt.Sprintf("usage: /%s <nick>", command))
+				return nil
+			}
+
+			wt.Start(strings.ToUpper(command), args[0])
+			c.Publish(rawOutputTopic, 0, false, fmt.Sprintf("%s %s", strings.ToUpper(command), args[0]))
+			return nil
+		}
+
+		if command == "join" {
+			if len(args) == 0 {
+				bl.Logger(channel).Log("usage: /join <channel> [key]")
+				return nil
+			}
+
+			target := joinChannelName(args[0])
+			key := argOrEmpty(args, 1)
+			chans.Add(target, key)
+			bl.Open(target)
+
+			c.Publish(rawOutputTopic, 0, false, formatJoinCommand(target, key))
+			return nil
+		}
+
+		if command == "part" {
+			target, reason := channel, getCommandText(b)
+
+			if len(args) > 0 && isChannel(args[0]) {
+				target = args[0]
+				reason = strings.TrimSpace(strings.TrimPrefix(reason, target))
+			}
+
+			if !isChannel(target) {
+				bl.Logger(channel).Log("usage: /part [channel] [reason]")
+				return nil
+			}
+
+			chans.Remove(target)
+			ml.Leave(target)
+			bl.Remove(target)
+
+			c.Publish(rawOutputTopic, 0, false, formatPartCommand(target, reason))
+			return nil
+		}
+
+		if command == "msg" || command == "query" {
+			if len(args) == 0 || isChannel(args[0]) {
+				bl.Logger(channel).Log(fmt.Sprintf("usage: /%s <nick> [text]", command))
+				return nil
+			}
+
+			nick := args[0]
+			text := strings.TrimSpace(strings.TrimPrefix(getCommandText(b), nick))
+
+			if command == "query" {
+				bl.Open(nick)
+			}
+
+			if text == "" {
+				return nil
+			}
+
+			return publish(nick, text)
+		}
+
+		if channel == statusBuffer {
+			bl.Logger(channel).Log("no channel selected")
+			return nil
+		}
+
 		if command == "ch" || command == "chatlog" {
 			hl := "10"
 
@@ -121,57 +511,111 @@ func genSendMessage(c mqtt.Client, module, topicRoot, channel string, l *logger)
 			return nil
 		}
 
+		if usage, p := channelCommandUsage[command]; p && !isChannel(channel) && !(command == "mode" && hasModeTarget(args)) {
+			bl.Logger(channel).Log(fmt.Sprintf("usage: %s (in a channel)", usage))
+			return nil
+		}
+
 		if command == "t" || command == "topic" {
-			c.Publish(rawOutputTopic, 0, false, fmt.Sprintf("TOPIC %s", channel))
+			c.Publish(rawOutputTopic, 0, false, formatTopicCommand(channel, getCommandText(b)))
 			return nil
 		}
 
-		if command == "n" || command == "names" {
-			c.Publish(rawOutputTopic, 0, false, fmt.Sprintf("NAMES %s", channel))
+		if command == "kick" {
+			if len(args) == 0 {
+				bl.Logger(channel).Log("usage: " + channelCommandUsage[command])
+				return nil
+			}
+
+			reason := strings.TrimSpace(strings.TrimPrefix(getCommandText(b), args[0]))
+			c.Publish(rawOutputTopic, 0, false, formatKickCommand(channel, args[0], reason))
 			return nil
 		}
 
-		if command == "c" || command == "clear" {
-			g.Update(func(g *gocui.Gui) error {
-				vc, err := g.View("chat")
-				if err != nil {
-					return err
-				}
+		if command == "ban" || command == "unban" {
+			if len(args) == 0 {
+				bl.Logger(channel).Log("usage: " + channelCommandUsage[command])
+				return nil
+			}
 
-				vc.Clear()
+			mode := "+b"
+			if command == "unban" {
+				mode = "-b"
+			}
 
+			masks := []string{}
+			for _, a := range args {
+				masks = append(masks, banMask(a, hc))
+			}
+
+			for _, line := range formatMemberModeCommands(channel, mode, masks) {
+				c.Publish(rawOutputTopic, 0, false, line)
+			}
+
+			return nil
+		}
+
+		if mode, p := memberModeCommands[command]; p {
+			if len(args) == 0 {
+				bl.Logger(channel).Log("usage: " + channelCommandUsage[command])
 				return nil
-			})
+			}
+
+			for _, line := range formatMemberModeCommands(channel, mode, args) {
+				c.Publish(rawOutputTopic, 0, false, line)
+			}
 
 			return nil
 		}
 
-		if strings.HasPrefix(b, "/") {
+		if command == "mode" {
+			c.Publish(rawOutputTopic, 0, false, formatModeCommand(channel, args))
+			return nil
+		}
+
+		if command == "n" || command == "names" {
+			c.Publish(rawOutputTopic, 0, false, fmt.Sprintf("NAMES %s", channel))
+			return nil
+		}
+
+		if command == "me" {
+			text := getCommandText(b)
+			if text == "" {
+				return nil
+			}
+
+			b = formatAction(text)
+		} else if strings.HasPrefix(b, "/") {
 			if !strings.HasPrefix(b, "//") {
-				l.Log("command not recognised")
+				bl.Logger(channel).Log("command not recognised")
 				return nil
 			}
 			b = strings.TrimPrefix(b, "/")
 		}
 
-		m := &gowon.Message{
-			Module: module,
-			Nick:   "you",
-			Dest:   channel,
-			Msg:    b,
-		}
+		return publish(channel, b)
+	}
+}
 
-		mj, err := json.Marshal(m)
+// genScrollActive scrolls the active chat view, calling onTop when scrolling
+// up past the oldest line shown
+func genScrollActive(bl *bufferList, y int, onTop func(buffer string)) func(g *gocui.Gui, v *gocui.View) error {
+	scroll := genScrollX(y)
+
+	return func(g *gocui.Gui, v *gocui.View) error {
+		cv, err := getChatView(g, bl.Active())
 		if err != nil {
-			l.Log(err.Error())
 			return err
 		}
 
-		c.Publish(inputTopic, 0, false, mj)
-		c.Publish(outputTopic, 0, false, mj)
-		v.Clear()
+		_, oy := cv.Origin()
+		_, h := cv.Size()
 
-		return nil
+		if y < 0 && (oy+y < 0 || cv.LinesHeight() < h) {
+			onTop(bl.Active())
+		}
+
+		return scroll(g, cv)
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/logrusorgru/aurora"
@@ -51,29 +52,37 @@ func sortNamesList(names []string) []string {
 	return nc
 }
 
+func colourName(name string, ca *colourAllocator) string {
+	sanitisedNick := strings.TrimLeftFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	index := ca.Allocate(sanitisedNick)
+
+	return aurora.Index(index, name).String()
+}
+
 func colourNamesList(names string, ca *colourAllocator) string {
 	namesList := sortNamesList(strings.Fields(names))
 
 	colouredNames := []string{}
 
 	for _, name := range namesList {
-		sanitisedNick := strings.TrimLeftFunc(name, func(r rune) bool {
-			return !unicode.IsLetter(r)
-		})
-		index := ca.Allocate(sanitisedNick)
-		colouredName := aurora.Index(index, name).String()
-		colouredNames = append(colouredNames, colouredName)
+		colouredNames = append(colouredNames, colourName(name, ca))
 	}
 
 	return strings.Join(colouredNames, " ")
 }
 
 type colourAllocator struct {
+	sync.Mutex
 	seed  int
 	cache map[string]uint8
 }
 
 func (c *colourAllocator) Allocate(s string) uint8 {
+	c.Lock()
+	defer c.Unlock()
+
 	v, p := c.cache[s]
 	if p {
 		return v
@@ -97,36 +106,6 @@ func createColourAllocator(i int) *colourAllocator {
 	}
 }
 
-func ircToAnsiColours(s string) string {
-	out := s
-
-	m := map[string]string{
-		"\u000301": "\x1b[30m", // red
-		"\u000302": "\x1b[34m", // navy blue -> blue
-		"\u000303": "\x1b[32m", // green
-		"\u000304": "\x1b[31m", // red
-		"\u000305": "\x1b[33m", // brown -> yellow
-		"\u000306": "\x1b[35m", // purple -> magenta
-		"\u000307": "\x1b[32m", // olive -> green
-		"\u000308": "\x1b[33m", // yellow
-		"\u000309": "\x1b[32m", // lime green -> green
-		"\u000310": "\x1b[36m", // teal -> cyan
-		"\u000311": "\x1b[36m", // aqua blue -> cyan
-		"\u000312": "\x1b[34m", // royal blue -> blue
-		"\u000313": "\x1b[31m", // hot pink -> red
-		"\u000314": "\x1b[30m", // dark grey -> black
-		"\u000315": "\x1b[30m", // light grey -> black
-		"\u000316": "\x1b[37m", // white
-		"\u000399": "\x1b[0m",  // reset
-	}
-
-	for k, v := range m {
-		out = strings.Replace(out, k, v, -1)
-	}
-
-	return out
-}
-
 func getCommand(s string) (command string, args []string) {
 	if !strings.HasPrefix(s, "/") {
 		return "", []string{}
@@ -138,6 +117,15 @@ func getCommand(s string) (command string, args []string) {
 	return command, fields[1:]
 }
 
+func getCommandText(s string) string {
+	command, _ := getCommand(s)
+	if command == "" {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(s, "/"+command))
+}
+
 func stringIsNumber(s string) bool {
 	_, err := strconv.Atoi(s)
 	return err == nil
@@ -206,42 +206,6 @@ func TestColourAllocatorCache(t *testing.T) {
 	assert.True(t, p)
 }
 
-func TestIrcToAnsiColours(t *testing.T) {
-	cases := []struct {
-		name string
-		in   string
-		out  string
-	}{
-		{
-			name: "empty string",
-			in:   "",
-			out:  "",
-		},
-		{
-			name: "no colours",
-			in:   "nako",
-			out:  "nako",
-		},
-		{
-			name: "one colour",
-			in:   "\u000301nako",
-			out:  "\x1b[30mnako",
-		},
-		{
-			name: "colour and reset",
-			in:   "\u000301nako\u000399",
-			out:  "\x1b[30mnako\x1b[0m",
-		},
-	}
-
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			got := ircToAnsiColours(tc.in)
-			assert.Equal(t, tc.out, got)
-		})
-	}
-}
-
 func TestGetCommand(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -282,6 +246,42 @@ func TestGetCommand(t *testing.T) {
 	}
 }
 
+func TestGetCommandText(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "empty string",
+			in:   "",
+			out:  "",
+		},
+		{
+			name: "no command",
+			in:   "nako",
+			out:  "",
+		},
+		{
+			name: "command, no text",
+			in:   "/me",
+			out:  "",
+		},
+		{
+			name: "command and text",
+			in:   "/me waves  at   nako",
+			out:  "waves  at   nako",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := getCommandText(tc.in)
+			assert.Equal(t, tc.out, got)
+		})
+	}
+}
+
 func TestStringIsNumber(t *testing.T) {
 	cases := []struct {
 		name string
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type whoisReply struct {
+	command    string
+	nick       string
+	user       string
+	host       string
+	realName   string
+	server     string
+	serverInfo string
+	operator   bool
+	channels   []string
+	idle       time.Duration
+	signon     time.Time
+	account    string
+	secure     bool
+}
+
+// whoisLookup is a WHOIS or WHOWAS in progress. WHOWAS can answer with
+// several past entries, each starting with its own 314.
+type whoisLookup struct {
+	command string
+	replies []*whoisReply
+}
+
+// whoisTracker collects the numerics of a WHOIS or WHOWAS reply until the
+// end numeric arrives, keyed by the folded nick being looked up
+type whoisTracker struct {
+	sync.Mutex
+	pending map[string]*whoisLookup
+}
+
+func (wt *whoisTracker) lookup(nick string) *whoisLookup {
+	key := strings.ToLower(nick)
+
+	wl, p := wt.pending[key]
+	if !p {
+		wl = &whoisLookup{command: "WHOIS"}
+		wt.pending[key] = wl
+	}
+
+	return wl
+}
+
+// reply returns the entry numerics for nick are added to, starting a new one
+// for a 314 or when there's none yet
+func (wt *whoisTracker) reply(code, nick string) *whoisReply {
+	wl := wt.lookup(nick)
+
+	if code == "314" || len(wl.replies) == 0 {
+		wl.replies = append(wl.replies, &whoisReply{command: wl.command, nick: nick})
+	}
+
+	return wl.replies[len(wl.replies)-1]
+}
+
+// Start begins a new lookup, dropping anything left from an earlier lookup of
+// the same nick that never finished
+func (wt *whoisTracker) Start(command, nick string) {
+	wt.Lock()
+	defer wt.Unlock()
+
+	wt.pending[strings.ToLower(nick)] = &whoisLookup{command: command}
+}
+
+// Add records a whois numeric, with args starting at the nick being looked
+// up, and reports whether the code is part of a whois reply
+func (wt *whoisTracker) Add(code string, args []string) bool {
+	if len(args) < 2 {
+		return false
+	}
+
+	wt.Lock()
+	defer wt.Unlock()
+
+	switch code {
+	case "311", "314":
+		if len(args) < 5 {
+			return false
+		}
+
+		w := wt.reply(code, args[0])
+		w.nick, w.user, w.host, w.realName = args[0], args[1], args[2], args[4]
+
+		if code == "314" {
+			w.command = "WHOWAS"
+		}
+	case "312":
+		w := wt.reply(code, args[0])
+		w.server = args[1]
+		w.serverInfo = argOrEmpty(args, 2)
+	case "313":
+		wt.reply(code, args[0]).operator = true
+	case "317":
+		w := wt.reply(code, args[0])
+
+		if secs, err := strconv.ParseInt(args[1], 10, 64); err == nil {
+			w.idle = time.Duration(secs) * time.Second
+		}
+
+		if len(args) > 3 {
+			if t, err := parseUnixTime(args[2]); err == nil {
+				w.signon = t
+			}
+		}
+	case "319":
+		w := wt.reply(code, args[0])
+		w.channels = append(w.channels, strings.Fields(args[1])...)
+	case "330":
+		wt.reply(code, args[0]).account = args[1]
+	case "671":
+		wt.reply(code, args[0]).secure = true
+	default:
+		return false
+	}
+
+	return true
+}
+
+// End returns the collected replies for nick. A lookup that only got an
+// error, like a missing nick, has none.
+func (wt *whoisTracker) End(nick string) []whoisReply {
+	wt.Lock()
+	defer wt.Unlock()
+
+	key := strings.ToLower(nick)
+
+	replies := []whoisReply{}
+
+	if wl, p := wt.pending[key]; p {
+		for _, w := range wl.replies {
+			replies = append(replies, *w)
+		}
+	}
+
+	delete(wt.pending, key)
+
+	return replies
+}
+
+func createWhoisTracker() *whoisTracker {
+	return &whoisTracker{
+		pending: make(map[string]*whoisLookup),
+	}
+}
+
+func formatWhois(w whoisReply, ck *clock) []string {
+	out := []string{fmt.Sprintf("--- %s %s ---", strings.ToLower(w.command), w.nick)}
+
+	if w.user != "" {
+		out = append(out, fmt.Sprintf("%s is %s@%s (%s)", w.nick, w.user, w.host, ircToAnsiColours(w.realName)))
+	}
+
+	if w.server != "" {
+		out = append(out, withReason(fmt.Sprintf("%s is connected to %s", w.nick, w.server), w.serverInfo))
+	}
+
+	if w.operator {
+		out = append(out, fmt.Sprintf("%s is an IRC operator", w.nick))
+	}
+
+	if len(w.channels) > 0 {
+		out = append(out, fmt.Sprintf("%s is in %s", w.nick, strings.Join(w.channels, " ")))
+	}
+
+	if w.idle > 0 || !w.signon.IsZero() {
+		idle := fmt.Sprintf("%s has been idle %s", w.nick, w.idle)
+
+		if !w.signon.IsZero() {
+			idle += fmt.Sprintf(", signed on %s", ck.Date(w.signon)+" "+ck.Format(w.signon))
+		}
+
+		out = append(out, idle)
+	}
+
+	if w.account != "" {
+		out = append(out, fmt.Sprintf("%s is logged in as %s", w.nick, w.account))
+	}
+
+	if w.secure {
+		out = append(out, fmt.Sprintf("%s is using a secure connection", w.nick))
+	}
+
+	return out
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhoisTracker(t *testing.T) {
+	wt := createWhoisTracker()
+
+	assert.True(t, wt.Add("311", []string{"Gowon", "~gowon", "example.com", "*", "Gowon Bot"}))
+	assert.True(t, wt.Add("312", []string{"gowon", "irc.example.com", "Example Server"}))
+	assert.True(t, wt.Add("313", []string{"gowon", "is an IRC operator"}))
+	assert.True(t, wt.Add("317", []string{"gowon", "65", "1666000000", "seconds idle, signon time"}))
+	assert.True(t, wt.Add("319", []string{"gowon", "@#nako #gowon"}))
+	assert.True(t, wt.Add("319", []string{"gowon", "+#other"}))
+	assert.True(t, wt.Add("330", []string{"gowon", "gowonacct", "is logged in as"}))
+	assert.True(t, wt.Add("671", []string{"gowon", "is using a secure connection"}))
+	assert.False(t, wt.Add("332", []string{"#nako", "topic"}))
+	assert.False(t, wt.Add("311", []string{"gowon"}))
+
+	assert.Equal(t, []whoisReply{{
+		command:    "WHOIS",
+		nick:       "Gowon",
+		user:       "~gowon",
+		host:       "example.com",
+		realName:   "Gowon Bot",
+		server:     "irc.example.com",
+		serverInfo: "Example Server",
+		operator:   true,
+		channels:   []string{"@#nako", "#gowon", "+#other"},
+		idle:       65 * time.Second,
+		signon:     time.Unix(1666000000, 0),
+		account:    "gowonacct",
+		secure:     true,
+	}}, wt.End("GOWON"))
+
+	assert.Empty(t, wt.End("gowon"))
+}
+
+func TestWhoisTrackerStart(t *testing.T) {
+	wt := createWhoisTracker()
+
+	wt.Start("WHOIS", "gowon")
+	wt.Add("312", []string{"gowon", "old.example.com", "Old Server"})
+	wt.Add("330", []string{"gowon", "gowonacct", "is logged in as"})
+
+	wt.Start("WHOWAS", "Gowon")
+	wt.Add("314", []string{"gowon", "~gowon", "example.com", "*", "Gowon Bot"})
+
+	assert.Equal(t, []whoisReply{{
+		command:  "WHOWAS",
+		nick:     "gowon",
+		user:     "~gowon",
+		host:     "example.com",
+		realName: "Gowon Bot",
+	}}, wt.End("gowon"))
+
+	wt.Add("314", []string{"nako", "~nako", "example.com", "*", "Nako"})
+	assert.Equal(t, "WHOWAS", wt.End("nako")[0].command)
+}
+
+func TestWhoisTrackerMissingNick(t *testing.T) {
+	wt := createWhoisTracker()
+
+	wt.Start("WHOIS", "gowon")
+	assert.Empty(t, wt.End("gowon"))
+
+	wt.Start("WHOWAS", "gowon")
+	assert.Empty(t, wt.End("gowon"))
+}
+
+func TestWhoisTrackerWhowasEntries(t *testing.T) {
+	wt := createWhoisTracker()
+
+	wt.Start("WHOWAS", "gowon")
+	wt.Add("314", []string{"gowon", "~gowon", "old.example.com", "*", "Gowon Bot"})
+	wt.Add("312", []string{"gowon", "irc.example.com", "Mon Oct 17 09:30:00 2022"})
+	wt.Add("314", []string{"gowon", "~gowon", "new.example.com", "*", "Gowon Bot"})
+	wt.Add("312", []string{"gowon", "irc.example.net", "Tue Oct 18 09:30:00 2022"})
+
+	assert.Equal(t, []whoisReply{
+		{
+			command:    "WHOWAS",
+			nick:       "gowon",
+			user:       "~gowon",
+			host:       "old.example.com",
+			realName:   "Gowon Bot",
+			server:     "irc.example.com",
+			serverInfo: "Mon Oct 17 09:30:00 2022",
+		},
+		{
+			command:    "WHOWAS",
+			nick:       "gowon",
+			user:       "~gowon",
+			host:       "new.example.com",
+			realName:   "Gowon Bot",
+			server:     "irc.example.net",
+			serverInfo: "Tue Oct 18 09:30:00 2022",
+		},
+	}, wt.End("gowon"))
+}
+
+func TestFormatWhois(t *testing.T) {
+	cases := []struct {
+		name string
+		in   whoisReply
+		out  []string
+	}{
+		{
+			name: "full reply",
+			in: whoisReply{
+				command:    "WHOIS",
+				nick:       "gowon",
+				user:       "~gowon",
+				host:       "example.com",
+				realName:   "Gowon Bot",
+				server:     "irc.example.com",
+				serverInfo: "Example Server",
+				operator:   true,
+				channels:   []string{"@#nako", "#gowon"},
+				idle:       65 * time.Second,
+				signon:     time.Date(2022, 10, 17, 9, 30, 0, 0, time.UTC),
+				account:    "gowonacct",
+				secure:     true,
+			},
+			out: []string{
+				"--- whois gowon ---",
+				"gowon is ~gowon@example.com (Gowon Bot)",
+				"gowon is connected to irc.example.com (Example Server)",
+				"gowon is an IRC operator",
+				"gowon is in @#nako #gowon",
+				"gowon has been idle 1m5s, signed on 2022-10-17 09:30",
+				"gowon is logged in as gowonacct",
+				"gowon is using a secure connection",
+			},
+		},
+		{
+			name: "whowas reply",
+			in: whoisReply{
+				command:  "WHOWAS",
+				nick:     "gowon",
+				user:     "~gowon",
+				host:     "example.com",
+				realName: "Gowon Bot",
+				server:   "irc.example.com",
+			},
+			out: []string{
+				"--- whowas gowon ---",
+				"gowon is ~gowon@example.com (Gowon Bot)",
+				"gowon is connected to irc.example.com",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.out, formatWhois(tc.in, testClock()))
+		})
+	}
+}
+
+func TestFormatWhoisClock(t *testing.T) {
+	w := whoisReply{
+		command: "WHOIS",
+		nick:    "gowon",
+		idle:    time.Minute,
+		signon:  time.Date(2022, 10, 17, 23, 30, 0, 0, time.UTC),
+	}
+
+	ck := &clock{loc: time.FixedZone("UTC+1", 60*60), layout: "3:04PM"}
+
+	assert.Equal(t, []string{
+		"--- whois gowon ---",
+		"gowon has been idle 1m0s, signed on 2022-10-18 12:30AM",
+	}, formatWhois(w, ck))
+}